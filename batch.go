@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Keys of the --multi actions that only make sense for a batch of PRs.
+const (
+	keyLabel    = "ctrl-l"
+	keyReviewer = "ctrl-t"
+	keyApprove  = "ctrl-v"
+)
+
+var batchKeys = []string{keyLabel, keyReviewer, keyApprove}
+
+// batchResult is the outcome of a batch action on one PR, named by
+// selection.label.
+type batchResult struct {
+	name string
+	err  error
+}
+
+// batchArgs returns the gh arguments, before -R and the PR number, that
+// apply the action of key to a PR. value is the label or reviewer asked for.
+// Enter, which prints the numbers, and copying run no command.
+func batchArgs(key, value string) []string {
+	switch key {
+	case keyWeb:
+		return []string{"pr", "view", "-w"}
+	case keyDiff:
+		return []string{"pr", "diff"}
+	case keyReady:
+		return []string{"pr", "ready"}
+	case keyLabel:
+		return []string{"pr", "edit", "--add-label", value}
+	case keyReviewer:
+		return []string{"pr", "edit", "--add-reviewer", value}
+	case keyApprove:
+		return []string{"pr", "review", "--approve"}
+	}
+	return nil
+}
+
+// runBatch applies the action of key to each of sels, going on after
+// failures, and reports the outcome for each PR at the end.
+func runBatch(key string, sels []selection, opt options) error {
+	ctx := context.Background()
+	if key == "" {
+		for _, sel := range sels {
+			if sel.number != 0 {
+				_, other := ghRepoFlag(ctx, opt, sel.repo)
+				fmt.Println(sel.label(other))
+			}
+		}
+		return nil
+	}
+
+	var value string
+	switch key {
+	case keyLabel, keyReviewer:
+		question := "Label to add: "
+		if key == keyReviewer {
+			question = "Reviewer to request: "
+		}
+		if value = prompt(question); value == "" {
+			return fmt.Errorf("cancelled")
+		}
+	}
+
+	var results []batchResult
+	var urls []string
+	for _, sel := range sels {
+		if sel.number == 0 {
+			results = append(results, batchResult{sel.label(false), fmt.Errorf("no pull request")})
+			continue
+		}
+		repoFlag, other := ghRepoFlag(ctx, opt, sel.repo)
+		name := sel.label(other)
+		number := strconv.Itoa(sel.number)
+		if key == keyCopy {
+			urls = append(urls, sel.url)
+			results = append(results, batchResult{name, nil})
+			continue
+		}
+
+		args := append(append(batchArgs(key, value), repoFlag...), number)
+		cmd := exec.Command("gh", args...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+		if err != nil {
+			err = fmt.Errorf("gh %s: %w", strings.Join(args[:2], " "), err)
+		}
+		results = append(results, batchResult{name, err})
+	}
+	if len(urls) > 0 {
+		copyToClipboard(strings.Join(urls, "\n"))
+	}
+
+	fmt.Fprint(os.Stderr, formatResults(results))
+	if failed := countFailed(results); failed > 0 {
+		return fmt.Errorf("%d of %d PRs failed", failed, len(results))
+	}
+	return nil
+}
+
+// prompt asks for a line on the terminal; fzf has released it by now.
+func prompt(question string) string {
+	fmt.Fprint(os.Stderr, question)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// formatResults renders one line per PR: a green ✓, or a red ✖ and the
+// error.
+func formatResults(results []batchResult) string {
+	var b strings.Builder
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(&b, "%s✖%s %s: %v\n", red, reset, r.name, r.err)
+		} else {
+			fmt.Fprintf(&b, "%s✓%s %s\n", green, reset, r.name)
+		}
+	}
+	return b.String()
+}
+
+func countFailed(results []batchResult) int {
+	n := 0
+	for _, r := range results {
+		if r.err != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestBatchArgs(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  []string
+	}{
+		{"", "", nil},
+		{keyCopy, "", nil},
+		{keyWeb, "", []string{"pr", "view", "-w"}},
+		{keyLabel, "needs qa", []string{"pr", "edit", "--add-label", "needs qa"}},
+		{keyReviewer, "alice", []string{"pr", "edit", "--add-reviewer", "alice"}},
+		{keyApprove, "", []string{"pr", "review", "--approve"}},
+	}
+	for _, tt := range tests {
+		if got := batchArgs(tt.key, tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("batchArgs(%q, %q) = %q, want %q", tt.key, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatResults(t *testing.T) {
+	results := []batchResult{
+		{"#42", nil},
+		{"ghes.example.com/o/r#7", errors.New("gh pr edit: exit status 1")},
+		{"main", errors.New("no pull request")},
+	}
+	got := regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(formatResults(results), "")
+	want := "✓ #42\n✖ ghes.example.com/o/r#7: gh pr edit: exit status 1\n✖ main: no pull request\n"
+	if got != want {
+		t.Errorf("formatResults() = %q, want %q", got, want)
+	}
+	if got := countFailed(results); got != 2 {
+		t.Errorf("countFailed() = %d, want 2", got)
+	}
+}
+
+func TestSelectionLabel(t *testing.T) {
+	tests := []struct {
+		sel   selection
+		other bool
+		want  string
+	}{
+		{selection{repo: "github.com/o/r", number: 42, ref: "fix"}, false, "#42"},
+		{selection{repo: "ghes.example.com/acme/app", number: 7, ref: "feat"}, true, "ghes.example.com/acme/app#7"},
+		{selection{ref: "main"}, false, "main"},
+	}
+	for _, tt := range tests {
+		if got := tt.sel.label(tt.other); got != tt.want {
+			t.Errorf("%+v.label(%v) = %q, want %q", tt.sel, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestHandleSelectionPrintsNumbers(t *testing.T) {
+	git := initTestRepo(t)
+	git(nil, "remote", "add", "origin", "https://github.com/o/r.git")
+	t.Setenv("GH_REPO", "")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	// Headers and branch rows are skipped, and Enter on several PRs runs no
+	// command.
+	lines := []string{
+		"\t\t\t\t\t# Review requested (2)",
+		"github.com/o/r\t42\tfix\t\t\t#42  alice",
+		"ghes.example.com/acme/app\t7\tfeat\t\t\t#7  bob",
+		"\t0\tmain\t\t\t#0  main",
+	}
+	err = handleSelection("", lines, options{})
+	w.Close()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("handleSelection() error = %v", err)
+	}
+	out, _ := io.ReadAll(r)
+	if got, want := string(out), "#42\nghes.example.com/acme/app#7\n"; got != want {
+		t.Errorf("handleSelection() printed %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+// fallbackBranchPatterns are listed when neither --branch nor the
+// gh-list-pr.branch git config is set.
+var fallbackBranchPatterns = []string{"main", "master", "develop", "staging"}
+
+// branchPatterns returns the glob patterns of branches shown as default
+// branch rows: the --branch flags, else the gh-list-pr.branch git config
+// values, else fallbackBranchPatterns.
+func branchPatterns(ctx context.Context, flagPatterns []string) []string {
+	if len(flagPatterns) > 0 {
+		return flagPatterns
+	}
+	if out, err := gitOutput(ctx, "config", "--get-all", "gh-list-pr.branch"); err == nil && out != "" {
+		return strings.Split(out, "\n")
+	}
+	return fallbackBranchPatterns
+}
+
+// remoteDefaultBranch detects the default branch of remote from its HEAD
+// ref, falling back to the repository metadata when the ref is not set
+// (e.g. the remote was added rather than cloned).
+func remoteDefaultBranch(ctx context.Context, remote string) string {
+	if out, err := gitOutput(ctx, "symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD"); err == nil {
+		return strings.TrimPrefix(out, remote+"/")
+	}
+
+	url, err := gitOutput(ctx, "remote", "get-url", remote)
+	if err != nil {
+		return ""
+	}
+	repo, err := repository.Parse(url)
+	if err != nil {
+		return ""
+	}
+	client, err := api.NewGraphQLClient(api.ClientOptions{Host: repo.Host})
+	if err != nil {
+		return ""
+	}
+	var resp struct {
+		Repository struct {
+			DefaultBranchRef struct {
+				Name string `json:"name"`
+			} `json:"defaultBranchRef"`
+		} `json:"repository"`
+	}
+	query := `query($owner: String!, $name: String!) {
+		repository(owner: $owner, name: $name) { defaultBranchRef { name } }
+	}`
+	vars := map[string]interface{}{"owner": repo.Owner, "name": repo.Name}
+	if err := client.DoWithContext(ctx, query, vars, &resp); err != nil {
+		return ""
+	}
+	return resp.Repository.DefaultBranchRef.Name
+}
+
+func matchBranch(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultBranches returns rows for the remote's default branch and for the
+// remote branches matching patterns.
+func defaultBranches(ctx context.Context, remote string, patterns []string) ([]PullRequest, error) {
+	out, err := gitOutput(ctx, "for-each-ref", "--format=%(refname:short)", "refs/remotes/"+remote+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	detected := remoteDefaultBranch(ctx, remote)
+
+	var branches []string
+	for _, line := range strings.Split(out, "\n") {
+		name, ok := strings.CutPrefix(strings.TrimSpace(line), remote+"/")
+		if !ok || name == "HEAD" {
+			continue
+		}
+		if name == detected || matchBranch(name, patterns) {
+			branches = append(branches, name)
+		}
+	}
+	sort.Strings(branches)
+
+	firstCommits := loadFirstCommits()
+	defer saveFirstCommits(firstCommits)
+
+	var prs []PullRequest
+	for _, branch := range branches {
+		epoch, err := branchFirstCommitTime(ctx, remote+"/"+branch, firstCommits)
+		if err != nil {
+			continue
+		}
+		prs = append(prs, PullRequest{
+			Number:      0,
+			Title:       branch,
+			HeadRefName: branch,
+			Author:      Author{Login: "system"},
+			CreatedAt:   epoch.UTC().Format("2006-01-02T15:04:05Z"),
+			IsDraft:     false,
+			Additions:   0,
+			Deletions:   0,
+		})
+	}
+	return prs, nil
+}
+
+// localBranches returns rows for local branches, titled with how far each
+// is ahead of and behind the base remote's default branch and its last
+// commit subject. Branches that have a PR or a default branch row are
+// dropped later by preparePRs.
+func localBranches(ctx context.Context, remoteOverride string) ([]PullRequest, error) {
+	out, err := gitOutput(ctx, "for-each-ref", "--format=%(refname:short)%09%(committerdate:unix)%09%(subject)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+
+	var base string
+	if r, err := baseRemote(ctx, remoteOverride); err == nil {
+		if name := remoteDefaultBranch(ctx, r.Name); name != "" {
+			base = r.Name + "/" + name
+		}
+	}
+
+	var prs []PullRequest
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		name, subject := fields[0], fields[2]
+		var epoch int64
+		if _, err := fmt.Sscanf(fields[1], "%d", &epoch); err != nil {
+			continue
+		}
+
+		var ahead, behind int
+		if base != "" {
+			if counts, err := gitOutput(ctx, "rev-list", "--left-right", "--count", base+"..."+name); err == nil {
+				fmt.Sscanf(counts, "%d %d", &behind, &ahead)
+			}
+		}
+
+		prs = append(prs, PullRequest{
+			Number:      0,
+			Title:       fmt.Sprintf("\u21e1%d \u21e3%d %s", ahead, behind, subject),
+			HeadRefName: name,
+			Author:      Author{Login: "local"},
+			CreatedAt:   time.Unix(epoch, 0).UTC().Format("2006-01-02T15:04:05Z"),
+			IsLocal:     true,
+		})
+	}
+	return prs, nil
+}
+
+// branchFirstCommitTime returns the date of the first commit reachable from
+// ref. Finding it walks the whole history, which takes seconds on large
+// repositories, so the result is cached by the tip SHA and kept as long as
+// the branch only moves forward.
+func branchFirstCommitTime(ctx context.Context, ref string, cache firstCommitCache) (time.Time, error) {
+	sha, err := gitOutput(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	key := ref
+	if dir, err := gitOutput(ctx, "rev-parse", "--absolute-git-dir"); err == nil {
+		key = dir + ":" + ref
+	}
+
+	if c, ok := cache[key]; ok {
+		if c.SHA == sha {
+			return time.Unix(c.Time, 0), nil
+		}
+		if exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", c.SHA, sha).Run() == nil {
+			cache[key] = firstCommit{SHA: sha, Time: c.Time}
+			return time.Unix(c.Time, 0), nil
+		}
+	}
+
+	out, err := gitOutput(ctx, "log", "--max-parents=0", "--pretty=format:%ct", sha)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var first int64
+	for _, line := range strings.Split(out, "\n") {
+		var epoch int64
+		if _, err := fmt.Sscanf(line, "%d", &epoch); err != nil {
+			continue
+		}
+		if first == 0 || epoch < first {
+			first = epoch
+		}
+	}
+	if first == 0 {
+		return time.Time{}, fmt.Errorf("no commits for %s", ref)
+	}
+	cache[key] = firstCommit{SHA: sha, Time: first}
+	return time.Unix(first, 0), nil
+}
+
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// initTestRepo creates a git repository in a temporary directory, makes it
+// the working directory and returns a function running git in it.
+func initTestRepo(t *testing.T) func(env []string, args ...string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git(nil, "init", "-q")
+	return git
+}
+
+func testCommit(git func(env []string, args ...string), date string) {
+	env := []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}
+	git(env, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--allow-empty", "-qm", date)
+}
+
+func TestBranchFirstCommitTime(t *testing.T) {
+	git := initTestRepo(t)
+	commit := func(date string) {
+		t.Helper()
+		testCommit(git, date)
+		git(nil, "update-ref", "refs/remotes/origin/main", "HEAD")
+	}
+	commit("2024-01-01T00:00:00Z")
+	commit("2024-06-01T00:00:00Z")
+
+	want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := firstCommitCache{}
+	got, err := branchFirstCommitTime(context.Background(), "origin/main", cache)
+	if err != nil {
+		t.Fatalf("branchFirstCommitTime() error = %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("branchFirstCommitTime() = %v, want %v", got, want)
+	}
+	if len(cache) != 1 {
+		t.Fatalf("cache has %d entries, want 1", len(cache))
+	}
+
+	// A fast-forward reuses the cached time without walking the history.
+	commit("2024-09-01T00:00:00Z")
+	for key, c := range cache {
+		cache[key] = firstCommit{SHA: c.SHA, Time: 42}
+	}
+	got, err = branchFirstCommitTime(context.Background(), "origin/main", cache)
+	if err != nil {
+		t.Fatalf("branchFirstCommitTime() error = %v", err)
+	}
+	if got.Unix() != 42 {
+		t.Errorf("branchFirstCommitTime() after fast-forward = %d, want cached 42", got.Unix())
+	}
+
+	if _, err := branchFirstCommitTime(context.Background(), "origin/missing", cache); err == nil {
+		t.Error("branchFirstCommitTime() for a missing ref should fail")
+	}
+}
+
+func TestDefaultBranches(t *testing.T) {
+	git := initTestRepo(t)
+	testCommit(git, "2024-01-01T00:00:00Z")
+	for _, ref := range []string{"trunk", "release/1.0", "feature", "main"} {
+		git(nil, "update-ref", "refs/remotes/upstream/"+ref, "HEAD")
+	}
+	git(nil, "update-ref", "refs/remotes/origin/develop", "HEAD")
+	git(nil, "symbolic-ref", "refs/remotes/upstream/HEAD", "refs/remotes/upstream/trunk")
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"fallback", fallbackBranchPatterns, []string{"main", "trunk"}},
+		{"glob", []string{"release/*"}, []string{"release/1.0", "trunk"}},
+		{"no_patterns", nil, []string{"trunk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prs, err := defaultBranches(context.Background(), "upstream", tt.patterns)
+			if err != nil {
+				t.Fatalf("defaultBranches() error = %v", err)
+			}
+			var got []string
+			for _, pr := range prs {
+				got = append(got, pr.HeadRefName)
+				if pr.Number != 0 || pr.CreatedAt != "2024-01-01T00:00:00Z" {
+					t.Errorf("row %+v, want number 0 created at 2024-01-01", pr)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("defaultBranches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchPatterns(t *testing.T) {
+	git := initTestRepo(t)
+	ctx := context.Background()
+
+	if got := branchPatterns(ctx, nil); !reflect.DeepEqual(got, fallbackBranchPatterns) {
+		t.Errorf("branchPatterns() = %v, want fallback %v", got, fallbackBranchPatterns)
+	}
+
+	git(nil, "config", "--add", "gh-list-pr.branch", "trunk")
+	git(nil, "config", "--add", "gh-list-pr.branch", "release/*")
+	if got, want := branchPatterns(ctx, nil), []string{"trunk", "release/*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("branchPatterns() = %v, want configured %v", got, want)
+	}
+
+	if got, want := branchPatterns(ctx, []string{"main"}), []string{"main"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("branchPatterns() = %v, want flags %v", got, want)
+	}
+}
+
+func TestLocalBranches(t *testing.T) {
+	git := initTestRepo(t)
+	git(nil, "remote", "add", "origin", "https://github.com/o/r.git")
+	testCommit(git, "2024-01-01T00:00:00Z")
+	git(nil, "branch", "-M", "main")
+	git(nil, "checkout", "-qb", "feature")
+	testCommit(git, "2024-02-01T00:00:00Z")
+	testCommit(git, "2024-03-01T00:00:00Z")
+	git(nil, "checkout", "-q", "main")
+	testCommit(git, "2024-04-01T00:00:00Z")
+	git(nil, "update-ref", "refs/remotes/origin/main", "HEAD")
+	git(nil, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/main")
+
+	prs, err := localBranches(context.Background(), "")
+	if err != nil {
+		t.Fatalf("localBranches() error = %v", err)
+	}
+	want := []PullRequest{
+		{HeadRefName: "feature", Title: "⇡2 ⇣1 2024-03-01T00:00:00Z", Author: Author{Login: "local"},
+			CreatedAt: "2024-03-01T00:00:00Z", IsLocal: true},
+		{HeadRefName: "main", Title: "⇡0 ⇣0 2024-04-01T00:00:00Z", Author: Author{Login: "local"},
+			CreatedAt: "2024-04-01T00:00:00Z", IsLocal: true},
+	}
+	if !reflect.DeepEqual(prs, want) {
+		t.Errorf("localBranches() = %+v, want %+v", prs, want)
+	}
+}
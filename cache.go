@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prCacheKey identifies a PR list by where it was fetched from and how it
+// was filtered.
+func prCacheKey(targets []searchTarget, searchOptions string) string {
+	var b strings.Builder
+	for _, t := range targets {
+		b.WriteString(t.host + "\x00" + t.scope + "\x00")
+	}
+	b.WriteString(searchOptions)
+	return b.String()
+}
+
+func prCachePath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(emojiCacheDir(), "prs", hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadCachedPRs returns the PR list last saved under key, regardless of its
+// age; callers are expected to revalidate it.
+func loadCachedPRs(key string) ([]PullRequest, bool) {
+	data, err := os.ReadFile(prCachePath(key))
+	if err != nil {
+		return nil, false
+	}
+	var prs []PullRequest
+	if err := json.Unmarshal(data, &prs); err != nil {
+		return nil, false
+	}
+	return prs, true
+}
+
+func saveCachedPRs(key string, prs []PullRequest) error {
+	path := prCachePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(prs)
+	if err != nil {
+		return err
+	}
+	// Write atomically so that a concurrent run never reads a partial file.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// firstCommit is the cached first commit time of a branch at tip SHA.
+type firstCommit struct {
+	SHA  string `json:"sha"`
+	Time int64  `json:"time"`
+}
+
+type firstCommitCache map[string]firstCommit
+
+func firstCommitCachePath() string {
+	return filepath.Join(emojiCacheDir(), "first-commits.json")
+}
+
+func loadFirstCommits() firstCommitCache {
+	cache := firstCommitCache{}
+	if data, err := os.ReadFile(firstCommitCachePath()); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+func saveFirstCommits(cache firstCommitCache) {
+	if err := os.MkdirAll(emojiCacheDir(), 0o755); err != nil {
+		return
+	}
+	data, _ := json.Marshal(cache)
+	_ = os.WriteFile(firstCommitCachePath(), data, 0o644)
+}
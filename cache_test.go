@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPRCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	targets := []searchTarget{{host: "github.com", scope: "repo:o/r"}}
+	key := prCacheKey(targets, "")
+
+	if _, ok := loadCachedPRs(key); ok {
+		t.Fatal("loadCachedPRs() hit on an empty cache")
+	}
+
+	prs := []PullRequest{
+		{Number: 1, Title: "t", HeadRefName: "b", Author: Author{Login: "alice"}, Checks: Checks{Pass: 1}},
+	}
+	if err := saveCachedPRs(key, prs); err != nil {
+		t.Fatalf("saveCachedPRs() error = %v", err)
+	}
+	got, ok := loadCachedPRs(key)
+	if !ok {
+		t.Fatal("loadCachedPRs() missed after save")
+	}
+	if len(got) != 1 || got[0].Number != 1 || got[0].Author.Login != "alice" || got[0].Checks.Pass != 1 {
+		t.Errorf("loadCachedPRs() = %+v, want %+v", got, prs)
+	}
+
+	if _, ok := loadCachedPRs(prCacheKey(targets, "--state all")); ok {
+		t.Error("loadCachedPRs() hit for different search options")
+	}
+	other := []searchTarget{{host: "github.com", scope: "repo:o/other"}}
+	if _, ok := loadCachedPRs(prCacheKey(other, "")); ok {
+		t.Error("loadCachedPRs() hit for a different repository")
+	}
+}
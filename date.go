@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateModes are the accepted --date values.
+var dateModes = []string{"iso", "local", "relative"}
+
+// dateFields are the accepted --date-field values.
+var dateFields = []string{"created", "updated", "merged"}
+
+// prDate returns the timestamp of pr selected by field. Branch rows only
+// have CreatedAt, which is used for every field.
+func prDate(pr PullRequest, field string) string {
+	if pr.Number == 0 {
+		return pr.CreatedAt
+	}
+	switch field {
+	case "updated":
+		return pr.UpdatedAt
+	case "merged":
+		return pr.MergedAt
+	}
+	return pr.CreatedAt
+}
+
+// displayDate is the text of the date column: Date when set by setDates,
+// else CreatedAt as is.
+func (pr PullRequest) displayDate() string {
+	if pr.Date != "" {
+		return pr.Date
+	}
+	return pr.CreatedAt
+}
+
+// formatDate renders an ISO 8601 timestamp as given ("iso"), in the local
+// time zone ("local") or relative to now ("relative"). Timestamps that do
+// not parse are returned as is.
+func formatDate(ts, mode string, now time.Time) string {
+	if mode == "iso" || ts == "" {
+		return ts
+	}
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	if mode == "local" {
+		return t.Local().Format("2006-01-02 15:04")
+	}
+	return relativeTime(now.Sub(t))
+}
+
+// relativeTime renders d like "3d ago", in the largest unit that fits.
+func relativeTime(d time.Duration) string {
+	const (
+		day   = 24 * time.Hour
+		week  = 7 * day
+		month = 30 * day
+		year  = 365 * day
+	)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", d/time.Minute)
+	case d < day:
+		return fmt.Sprintf("%dh ago", d/time.Hour)
+	case d < week:
+		return fmt.Sprintf("%dd ago", d/day)
+	case d < month:
+		return fmt.Sprintf("%dw ago", d/week)
+	case d < year:
+		return fmt.Sprintf("%dmo ago", d/month)
+	}
+	return fmt.Sprintf("%dy ago", d/year)
+}
+
+// setDates fills in the Date display field of prs for the --date and
+// --date-field options.
+func setDates(prs []PullRequest, opt options, now time.Time) {
+	for i := range prs {
+		prs[i].Date = formatDate(prDate(prs[i], opt.dateField), opt.dateMode, now)
+		if prs[i].Date == "" {
+			prs[i].Date = "-" // e.g. not merged yet
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDate(t *testing.T) {
+	t.Setenv("TZ", "")
+	local := time.Local
+	time.Local = time.FixedZone("JST", 9*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		ts   string
+		mode string
+		want string
+	}{
+		{"iso", "2025-01-15T10:00:00Z", "iso", "2025-01-15T10:00:00Z"},
+		{"local", "2025-01-15T20:30:00Z", "local", "2025-01-16 05:30"},
+		{"just_now", "2025-03-01T11:59:30Z", "relative", "just now"},
+		{"minutes", "2025-03-01T11:15:00Z", "relative", "45m ago"},
+		{"hours", "2025-03-01T07:00:00Z", "relative", "5h ago"},
+		{"days", "2025-02-26T12:00:00Z", "relative", "3d ago"},
+		{"weeks", "2025-02-01T12:00:00Z", "relative", "4w ago"},
+		{"months", "2024-12-01T12:00:00Z", "relative", "3mo ago"},
+		{"years", "2022-06-01T12:00:00Z", "relative", "2y ago"},
+		{"empty", "", "relative", ""},
+		{"unparsable", "yesterday", "local", "yesterday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDate(tt.ts, tt.mode, now); got != tt.want {
+				t.Errorf("formatDate(%q, %q) = %q, want %q", tt.ts, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDates(t *testing.T) {
+	prs := []PullRequest{
+		{Number: 1, CreatedAt: "c1", UpdatedAt: "u1", MergedAt: "m1"},
+		{Number: 2, CreatedAt: "c2", UpdatedAt: "u2"},
+		{Number: 0, CreatedAt: "c0"},
+	}
+	tests := []struct {
+		field string
+		want  []string
+	}{
+		{"created", []string{"c1", "c2", "c0"}},
+		{"updated", []string{"u1", "u2", "c0"}},
+		{"merged", []string{"m1", "-", "c0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			setDates(prs, options{dateMode: "iso", dateField: tt.field}, time.Now())
+			for i, pr := range prs {
+				if pr.Date != tt.want[i] {
+					t.Errorf("PR #%d Date = %q, want %q", pr.Number, pr.Date, tt.want[i])
+				}
+			}
+		})
+	}
+}
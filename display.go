@@ -2,7 +2,6 @@ package main
 
 import (
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -28,37 +27,71 @@ func termWidth() int {
 	return 80
 }
 
-var (
-	borderRe  = regexp.MustCompile(`--border(?:=(\S+))?`)
-	paddingRe = regexp.MustCompile(`--padding[= ]'?([^'"\s]+)'?`)
-)
-
+// fzfMargin returns the columns fzf takes from the terminal width for its
+// pointer, border and padding.
 func fzfMargin(opt options) int {
 	if opt.print {
 		return 0
 	}
-	allOpts := os.Getenv("FZF_DEFAULT_OPTS") + " " + opt.fzfOptions
+	// Unparsable options are reported by fzf or runFzf, not here.
+	defaults, _ := splitShellWords(os.Getenv("FZF_DEFAULT_OPTS"))
+	extra, _ := splitShellWords(opt.fzfOptions)
+	// The same order as runFzf passes them in.
+	args := append(append(defaults, previewArgs(opt)...), extra...)
 	margin := 2 // fzf pointer/indicator
 
-	// --border detection (last match wins)
+	// Later options override earlier ones, as in fzf.
 	style := ""
-	for _, m := range borderRe.FindAllStringSubmatch(allOpts, -1) {
-		if m[1] != "" {
-			style = m[1]
-		} else {
+	padding := ""
+	preview := ""
+	window := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--preview":
+			if i+1 < len(args) {
+				i++
+				preview = args[i]
+			}
+		case strings.HasPrefix(arg, "--preview="):
+			preview = strings.TrimPrefix(arg, "--preview=")
+		case arg == "--preview-window":
+			if i+1 < len(args) {
+				i++
+				window = args[i]
+			}
+		case strings.HasPrefix(arg, "--preview-window="):
+			window = strings.TrimPrefix(arg, "--preview-window=")
+		case arg == "--border":
 			style = "rounded"
+			if i+1 < len(args) && borderMargin(args[i+1]) >= 0 {
+				i++
+				style = args[i]
+			}
+		case strings.HasPrefix(arg, "--border="):
+			style = strings.TrimPrefix(arg, "--border=")
+		case arg == "--no-border":
+			style = ""
+		case arg == "--padding":
+			if i+1 < len(args) {
+				i++
+				padding = args[i]
+			}
+		case strings.HasPrefix(arg, "--padding="):
+			padding = strings.TrimPrefix(arg, "--padding=")
 		}
 	}
-	switch style {
-	case "rounded", "sharp", "bold", "double", "block", "thinblock", "vertical":
-		margin += 2
-	case "left", "right":
-		margin += 1
+
+	if m := borderMargin(style); m > 0 {
+		margin += m
+	}
+	if preview != "" {
+		margin += previewMargin(window, termWidth()-margin)
 	}
 
 	// --padding detection (add left+right)
-	if m := paddingRe.FindStringSubmatch(allOpts); m != nil {
-		parts := strings.Split(m[1], ",")
+	if padding != "" {
+		parts := strings.Split(padding, ",")
 		switch len(parts) {
 		case 1:
 			if v, err := strconv.Atoi(parts[0]); err == nil {
@@ -82,6 +115,59 @@ func fzfMargin(opt options) int {
 	return margin
 }
 
+// previewMargin returns the columns a side preview window with the fzf
+// --preview-window spec window takes from a list of width columns,
+// including its border. Windows above or below the list take none.
+func previewMargin(window string, width int) int {
+	position := "right"
+	size := "50%"
+	border := 2
+	for _, opt := range strings.FieldsFunc(window, func(r rune) bool { return r == ',' || r == ':' }) {
+		switch {
+		case opt == "up" || opt == "down" || opt == "top" || opt == "bottom" || opt == "left" || opt == "right":
+			position = opt
+		case opt == "hidden":
+			return 0
+		case opt == "noborder" || opt == "border-none":
+			border = 0
+		case opt == "border-left" || opt == "border-right" || opt == "border-vertical":
+			border = 1
+		case strings.HasPrefix(opt, "border"):
+			border = 2
+		case opt != "" && (opt[0] >= '0' && opt[0] <= '9'):
+			size = opt
+		}
+	}
+	if position != "left" && position != "right" {
+		return 0
+	}
+
+	cols := 0
+	if pct, ok := strings.CutSuffix(size, "%"); ok {
+		if v, err := strconv.Atoi(pct); err == nil {
+			cols = width * v / 100
+		}
+	} else if v, err := strconv.Atoi(size); err == nil {
+		cols = v + border
+	}
+	// Round up to a full column of spacing so that rows never wrap.
+	return cols + 1
+}
+
+// borderMargin returns the columns taken by an fzf border style, or -1 when
+// style is not a border style.
+func borderMargin(style string) int {
+	switch style {
+	case "rounded", "sharp", "bold", "double", "block", "thinblock", "vertical":
+		return 2
+	case "left", "right":
+		return 1
+	case "none", "top", "bottom", "horizontal":
+		return 0
+	}
+	return -1
+}
+
 func displayWidth(s string) int {
 	return runewidth.StringWidth(s)
 }
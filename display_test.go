@@ -88,6 +88,13 @@ func TestFzfMargin(t *testing.T) {
 		{"env_override", options{fzfOptions: "--border=none"}, "--border=rounded", 2},
 		{"env_only", options{}, "--border=rounded", 4},
 		{"invalid_padding", options{fzfOptions: "--padding abc"}, "", 2},
+		{"padding_quoted", options{fzfOptions: "--padding='1,3'"}, "", 8},
+		{"padding_last_wins", options{fzfOptions: "--padding 1 --padding 2"}, "", 6},
+		{"border_separate_style", options{fzfOptions: "--border sharp"}, "", 4},
+		{"border_then_other_option", options{fzfOptions: "--border --height 50%"}, "", 4},
+		{"no_border", options{fzfOptions: "--no-border"}, "--border=rounded", 2},
+		{"border_inside_header", options{fzfOptions: "--header='--border=rounded'"}, "", 2},
+		{"unbalanced_quote", options{fzfOptions: "--border '"}, "", 2},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -97,6 +104,45 @@ func TestFzfMargin(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("preview", func(t *testing.T) {
+		t.Setenv("FZF_DEFAULT_OPTS", "")
+		t.Setenv("COLUMNS", "102")
+		opt := options{preview: true, previewWindow: "right,50%"}
+		if got, want := fzfMargin(opt), 2+50+1; got != want {
+			t.Errorf("fzfMargin() = %d, want %d", got, want)
+		}
+		// A user --preview-window overrides --preview's.
+		opt.fzfOptions = "--preview-window=down"
+		if got := fzfMargin(opt); got != 2 {
+			t.Errorf("fzfMargin() with a bottom preview = %d, want 2", got)
+		}
+		// So does a user --preview without --preview of our own.
+		if got, want := fzfMargin(options{fzfOptions: "--preview 'cat {}' --preview-window 40"}), 2+40+2+1; got != want {
+			t.Errorf("fzfMargin() with a user preview = %d, want %d", got, want)
+		}
+	})
+}
+
+func TestPreviewMargin(t *testing.T) {
+	tests := []struct {
+		window string
+		want   int
+	}{
+		{"", 51},
+		{"right,50%", 51},
+		{"left:30%", 31},
+		{"right,60,border-left", 62},
+		{"40,noborder", 41},
+		{"down,50%", 0},
+		{"up:3", 0},
+		{"right,50%,hidden", 0},
+	}
+	for _, tt := range tests {
+		if got := previewMargin(tt.window, 100); got != tt.want {
+			t.Errorf("previewMargin(%q, 100) = %d, want %d", tt.window, got, tt.want)
+		}
+	}
 }
 
 func TestTermWidth(t *testing.T) {
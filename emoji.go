@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -24,12 +25,14 @@ func emojiCacheDir() string {
 	return filepath.Join(cacheDir, "gh", "gh-list-pr")
 }
 
-func emojiCachePath() string {
-	return filepath.Join(emojiCacheDir(), "emoji.json")
+// emojiCachePath is per host, since GitHub Enterprise Server instances may
+// serve a different emoji set than github.com.
+func emojiCachePath(host string) string {
+	return filepath.Join(emojiCacheDir(), "emoji-"+host+".json")
 }
 
-func loadEmoji() (map[string]string, error) {
-	cachePath := emojiCachePath()
+func loadEmoji(ctx context.Context, host string) (map[string]string, error) {
+	cachePath := emojiCachePath(host)
 
 	if info, err := os.Stat(cachePath); err == nil {
 		if time.Since(info.ModTime()) < 7*24*time.Hour {
@@ -43,17 +46,17 @@ func loadEmoji() (map[string]string, error) {
 		}
 	}
 
-	return fetchAndCacheEmoji(cachePath)
+	return fetchAndCacheEmoji(ctx, host, cachePath)
 }
 
-func fetchAndCacheEmoji(cachePath string) (map[string]string, error) {
-	client, err := api.DefaultRESTClient()
+func fetchAndCacheEmoji(ctx context.Context, host, cachePath string) (map[string]string, error) {
+	client, err := api.NewRESTClient(api.ClientOptions{Host: host})
 	if err != nil {
 		return nil, fmt.Errorf("create REST client: %w", err)
 	}
 
 	var raw map[string]string
-	if err := client.Get("emojis", &raw); err != nil {
+	if err := client.DoWithContext(ctx, "GET", "emojis", nil, &raw); err != nil {
 		return nil, fmt.Errorf("fetch emojis: %w", err)
 	}
 
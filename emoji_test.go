@@ -102,14 +102,18 @@ func TestEmojiCacheDir(t *testing.T) {
 }
 
 func TestEmojiCachePath(t *testing.T) {
-	got := emojiCachePath()
 	cacheDir, err := os.UserCacheDir()
 	if err != nil {
 		home, _ := os.UserHomeDir()
 		cacheDir = filepath.Join(home, ".cache")
 	}
-	want := filepath.Join(cacheDir, "gh", "gh-list-pr", "emoji.json")
-	if got != want {
-		t.Errorf("emojiCachePath() = %q, want %q", got, want)
+	for host, file := range map[string]string{
+		"github.com":         "emoji-github.com.json",
+		"github.example.com": "emoji-github.example.com.json",
+	} {
+		want := filepath.Join(cacheDir, "gh", "gh-list-pr", file)
+		if got := emojiCachePath(host); got != want {
+			t.Errorf("emojiCachePath(%q) = %q, want %q", host, got, want)
+		}
 	}
 }
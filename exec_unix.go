@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"syscall"
 )
 
@@ -17,3 +18,13 @@ func execCommand(name string, args ...string) error {
 	argv := append([]string{name}, args...)
 	return syscall.Exec(bin, argv, os.Environ())
 }
+
+// catCommand returns a shell command, as run by fzf, that prints path.
+func catCommand(path string) string {
+	return "cat " + shellQuote(path)
+}
+
+// shellQuote quotes s as a single word for the shell fzf runs commands in.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -14,3 +14,14 @@ func execCommand(name string, args ...string) error {
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
+
+// catCommand returns a cmd.exe command, as run by fzf, that prints path.
+func catCommand(path string) string {
+	return "type " + shellQuote(path)
+}
+
+// shellQuote quotes s as a single word for cmd.exe, which fzf runs
+// commands in.
+func shellQuote(s string) string {
+	return `"` + s + `"`
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -9,9 +10,12 @@ const (
 	reset       = "\033[0m"
 	green       = "\033[32m"
 	red         = "\033[31m"
+	yellow      = "\033[33m"
+	blue        = "\033[34m"
 	cyan        = "\033[36m"
 	magenta     = "\033[35m"
 	brightBlack = "\033[90m"
+	bold        = "\033[1m"
 )
 
 func buildLine(pr PullRequest, layout ColumnLayout) string {
@@ -19,10 +23,20 @@ func buildLine(pr PullRequest, layout ColumnLayout) string {
 
 	// PR number
 	numColor := green
+	branchColor := cyan
 	if pr.IsDraft {
 		numColor = brightBlack
 	}
-	fmt.Fprintf(&b, "%s#%-*d  %s", numColor, layout.NumWidth, pr.Number, reset)
+	if pr.IsLocal {
+		numColor = yellow
+		branchColor = yellow
+	}
+	if layout.ShowRepo {
+		ref := fmt.Sprintf("%s#%d", pr.Repository, pr.Number)
+		fmt.Fprintf(&b, "%s%s  %s", numColor, truncatePad(ref, layout.RepoWidth+1+layout.NumWidth), reset)
+	} else {
+		fmt.Fprintf(&b, "%s#%-*d  %s", numColor, layout.NumWidth, pr.Number, reset)
+	}
 
 	// Author
 	if layout.ShowAuthor {
@@ -31,17 +45,56 @@ func buildLine(pr PullRequest, layout ColumnLayout) string {
 
 	// Title
 	if layout.ShowTitle {
-		fmt.Fprintf(&b, "%s  ", truncatePad(pr.Title, layout.TitleWidth))
+		width := layout.TitleWidth
+		if pw := displayWidth(pr.TreePrefix); pw > 0 {
+			fmt.Fprintf(&b, "%s%s%s", brightBlack, truncatePad(pr.TreePrefix, min(pw, width)), reset)
+			width -= min(pw, width)
+		}
+		if pr.Unread {
+			fmt.Fprintf(&b, "%s%s%s  ", bold, truncatePad(pr.Title, width), reset)
+		} else {
+			fmt.Fprintf(&b, "%s  ", truncatePad(pr.Title, width))
+		}
+	}
+
+	// Labels
+	if layout.ShowLabels {
+		fmt.Fprintf(&b, "%s  ", formatLabels(pr.Labels, layout.LabelsWidth))
+	}
+
+	// Base branch, before the head branch
+	if layout.ShowBase {
+		fmt.Fprintf(&b, "%s%s  %s", blue, truncatePad(pr.BaseRefName, layout.BaseWidth), reset)
 	}
 
 	// Branch
-	fmt.Fprintf(&b, "%s%s  %s", cyan, truncatePad(pr.HeadRefName, layout.HeadRefWidth), reset)
+	fmt.Fprintf(&b, "%s%s  %s", branchColor, truncatePad(pr.HeadRefName, layout.HeadRefWidth), reset)
 
 	// Additions/Deletions
 	fmt.Fprintf(&b, "%s+%*d%s/%s-%*d%s",
 		green, layout.AddWidth, pr.Additions, reset,
 		red, layout.DelWidth, pr.Deletions, reset)
 
+	// Checks
+	if layout.ShowChecks {
+		fmt.Fprintf(&b, "  %s", formatChecks(pr.Checks, layout.ChecksWidth))
+	}
+
+	// Mergeability
+	if layout.ShowMerge {
+		fmt.Fprintf(&b, "  %s", formatMerge(pr))
+	}
+
+	// Review
+	if layout.ShowReview {
+		fmt.Fprintf(&b, "  %s", formatReview(pr, layout.ReviewWidth))
+	}
+
+	// Comments and review threads
+	if layout.ShowComments {
+		fmt.Fprintf(&b, "  %s", formatComments(pr, layout.CommentsWidth))
+	}
+
 	// Changed files
 	if layout.ShowFiles {
 		fmt.Fprintf(&b, "  %*d files", layout.FileWidth, pr.ChangedFiles)
@@ -49,17 +102,207 @@ func buildLine(pr PullRequest, layout ColumnLayout) string {
 
 	// Date
 	if layout.ShowDate {
-		fmt.Fprintf(&b, "  %s%s%s", brightBlack, pr.CreatedAt, reset)
+		fmt.Fprintf(&b, "  %s%s%s", brightBlack, truncatePad(pr.displayDate(), layout.DateWidth), reset)
 	}
 
 	return b.String()
 }
 
+// checksText summarizes checks as passed/total with gh pr checks' glyphs.
+func checksText(c Checks) string {
+	switch {
+	case c.Total() == 0:
+		return "-"
+	case c.Fail > 0:
+		return fmt.Sprintf("X %d/%d", c.Pass, c.Total())
+	case c.Pending > 0:
+		return fmt.Sprintf("* %d/%d", c.Pass, c.Total())
+	default:
+		return fmt.Sprintf("\u2713 %d/%d", c.Pass, c.Total())
+	}
+}
+
+func formatChecks(c Checks, width int) string {
+	color := green
+	switch {
+	case c.Total() == 0:
+		color = brightBlack
+	case c.Fail > 0:
+		color = red
+	case c.Pending > 0:
+		color = yellow
+	}
+	return color + truncatePad(checksText(c), width) + reset
+}
+
+// reviewText summarizes the review state, e.g. "✔ 2" for two approvals,
+// "✖ alice" for changes requested or "◌ bob,carol" for pending requests.
+func reviewText(pr PullRequest) string {
+	var approved, changes []string
+	for _, r := range pr.LatestReviews {
+		switch r.State {
+		case "APPROVED":
+			approved = append(approved, r.Author)
+		case "CHANGES_REQUESTED":
+			changes = append(changes, r.Author)
+		}
+	}
+	switch {
+	case pr.ReviewDecision == "CHANGES_REQUESTED",
+		pr.ReviewDecision != "APPROVED" && len(changes) > 0:
+		return strings.TrimSpace("\u2716 " + strings.Join(changes, ","))
+	case pr.ReviewDecision == "APPROVED", len(approved) > 0:
+		return fmt.Sprintf("\u2714 %d", len(approved))
+	case len(pr.ReviewRequests) > 0:
+		return "\u25cc " + strings.Join(pr.ReviewRequests, ",")
+	case pr.ReviewDecision == "REVIEW_REQUIRED":
+		return "\u25cc"
+	}
+	return ""
+}
+
+func formatReview(pr PullRequest, width int) string {
+	text := reviewText(pr)
+	color := brightBlack
+	switch {
+	case text == "":
+		text = "-"
+	case strings.HasPrefix(text, "\u2716"):
+		color = red
+	case strings.HasPrefix(text, "\u2714"):
+		color = green
+	default:
+		color = yellow
+	}
+	return color + truncatePad(text, width) + reset
+}
+
+// keyFields is the number of hidden tab-delimited fields before the visible
+// line that fzf shows with --with-nth.
+const keyFields = 5
+
+// lineKeys are the hidden fields that identify the row of pr exactly,
+// whatever its visible columns: the repository as HOST/OWNER/NAME (see
+// repoKey), the PR number (0 for a
+// branch), the full head branch, the URL and "local" for a local branch
+// row. Section headers have empty keys.
+func lineKeys(pr PullRequest) string {
+	if pr.Number == 0 && pr.HeadRefName == "" {
+		return strings.Repeat("\t", keyFields)
+	}
+	local := ""
+	if pr.IsLocal {
+		local = "local"
+	}
+	return strings.Join([]string{pr.repoKey(), strconv.Itoa(pr.Number), pr.HeadRefName, pr.URL, local}, "\t") + "\t"
+}
+
 func formatLines(prs []PullRequest, layout ColumnLayout) string {
 	var b strings.Builder
-	for _, pr := range prs {
+	for i, pr := range prs {
+		if pr.Section != "" && (i == 0 || prs[i-1].Section != pr.Section) {
+			if layout.Keys {
+				b.WriteString(lineKeys(PullRequest{}))
+			}
+			b.WriteString(sectionHeader(pr.Section, prs[i:]))
+			b.WriteByte('\n')
+		}
+		if layout.Keys {
+			b.WriteString(lineKeys(pr))
+		}
 		b.WriteString(buildLine(pr, layout))
 		b.WriteByte('\n')
 	}
 	return b.String()
 }
+
+// commentsText summarizes the conversation, e.g. "3c 2/5t" for 3 comments
+// and 2 unresolved out of 5 review threads, or "" when there is none.
+func commentsText(pr PullRequest) string {
+	if pr.Comments == 0 && pr.ReviewThreads == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dc %d/%dt", pr.Comments, pr.UnresolvedThreads, pr.ReviewThreads)
+}
+
+func formatComments(pr PullRequest, width int) string {
+	text := commentsText(pr)
+	color := ""
+	switch {
+	case text == "":
+		text = "-"
+		color = brightBlack
+	case pr.UnresolvedThreads > 0:
+		color = yellow
+	}
+	if color == "" {
+		return truncatePad(text, width)
+	}
+	return color + truncatePad(text, width) + reset
+}
+
+// sectionHeaderPrefix starts the header lines of --inbox sections. It cannot
+// be mistaken for a PR line, which has a digit right after the '#'.
+const sectionHeaderPrefix = "# "
+
+// sectionHeader renders the header of the section that prs start with,
+// counting its PRs.
+func sectionHeader(section string, prs []PullRequest) string {
+	n := 0
+	for _, pr := range prs {
+		if pr.Section != section {
+			break
+		}
+		n++
+	}
+	return fmt.Sprintf("%s%s%s (%d)%s", bold, sectionHeaderPrefix, section, n, reset)
+}
+
+// mergeText returns a one-column glyph for whether pr can be merged into its
+// base, or "" when GitHub has not reported it (e.g. branch rows).
+func mergeText(pr PullRequest) string {
+	if pr.Mergeable == "CONFLICTING" {
+		return "\u2716" // conflicting
+	}
+	switch pr.MergeStateStatus {
+	case "DIRTY":
+		return "\u2716" // conflicting
+	case "BEHIND":
+		return "\u21e3" // behind the base branch
+	case "BLOCKED":
+		return "\u2298" // blocked by required reviews or checks
+	case "UNSTABLE":
+		return "\u26a0" // mergeable with failing non-required checks
+	case "CLEAN", "HAS_HOOKS":
+		return "\u2714"
+	case "DRAFT", "UNKNOWN":
+		return "-"
+	}
+	return ""
+}
+
+func formatMerge(pr PullRequest) string {
+	text := mergeText(pr)
+	color := brightBlack
+	switch text {
+	case "":
+		text = "-"
+	case "\u2716":
+		color = red
+	case "\u21e3", "\u2298", "\u26a0":
+		color = yellow
+	case "\u2714":
+		color = green
+	}
+	return color + truncatePad(text, 1) + reset
+}
+
+// isMergeable reports whether pr can be merged right now: no conflicts and
+// nothing blocking it.
+func isMergeable(pr PullRequest) bool {
+	switch pr.MergeStateStatus {
+	case "CLEAN", "HAS_HOOKS", "UNSTABLE":
+		return pr.Mergeable != "CONFLICTING"
+	}
+	return false
+}
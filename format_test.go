@@ -1,23 +1,25 @@
 package main
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 )
 
 func TestBuildLine(t *testing.T) {
 	baseLayout := ColumnLayout{
-		NumWidth:    4,
-		AddWidth:    3,
-		DelWidth:    3,
-		FileWidth:   2,
-		TitleWidth:  20,
-		AuthorWidth: 10,
+		NumWidth:     4,
+		AddWidth:     3,
+		DelWidth:     3,
+		FileWidth:    2,
+		TitleWidth:   20,
+		AuthorWidth:  10,
 		HeadRefWidth: 15,
-		ShowFiles:   true,
-		ShowDate:    true,
-		ShowTitle:   true,
-		ShowAuthor:  true,
+		DateWidth:    20,
+		ShowFiles:    true,
+		ShowDate:     true,
+		ShowTitle:    true,
+		ShowAuthor:   true,
 	}
 
 	basePR := PullRequest{
@@ -53,6 +55,30 @@ func TestBuildLine(t *testing.T) {
 		}
 	})
 
+	t.Run("unread_pr", func(t *testing.T) {
+		if got := buildLine(basePR, baseLayout); strings.Contains(got, bold) {
+			t.Error("buildLine() read PR should not be bold")
+		}
+		unread := basePR
+		unread.Unread = true
+		if got := buildLine(unread, baseLayout); !strings.Contains(got, bold+"Fix critical bug") {
+			t.Errorf("buildLine() unread PR should have a bold title: %q", got)
+		}
+	})
+
+	t.Run("stacked_pr", func(t *testing.T) {
+		stacked := basePR
+		stacked.TreePrefix = "│  └─ "
+		ansi := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+		got := ansi.ReplaceAllString(buildLine(stacked, baseLayout), "")
+		if !strings.Contains(got, "│  └─ Fix critical …  ") {
+			t.Errorf("buildLine() stacked PR should draw the tree before the title: %q", got)
+		}
+		if displayWidth(got) != displayWidth(ansi.ReplaceAllString(buildLine(basePR, baseLayout), "")) {
+			t.Error("buildLine() tree prefix should fit in the title width")
+		}
+	})
+
 	t.Run("hide_title_and_author", func(t *testing.T) {
 		layout := baseLayout
 		layout.ShowTitle = false
@@ -77,6 +103,40 @@ func TestBuildLine(t *testing.T) {
 	})
 }
 
+func TestBuildLineRepo(t *testing.T) {
+	layout := ColumnLayout{RepoWidth: 9, NumWidth: 4, AddWidth: 1, DelWidth: 1, HeadRefWidth: 6, ShowRepo: true}
+	pr := PullRequest{Number: 7, Repository: "cli/cli", HeadRefName: "branch"}
+	got := regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(buildLine(pr, layout), "")
+	if !strings.HasPrefix(got, "cli/cli#7  ") {
+		t.Errorf("buildLine() = %q, want the repository before the number", got)
+	}
+}
+
+func TestLineKeys(t *testing.T) {
+	// The branch column is truncated, but the selection is exact.
+	layout := ColumnLayout{NumWidth: 2, AddWidth: 1, DelWidth: 1, HeadRefWidth: 8, Keys: true}
+	pr := PullRequest{Number: 42, Repository: "o/r", Host: "ghes.example.com", HeadRefName: "feature/very-long-branch", URL: "https://ghes.example.com/o/r/pull/42"}
+	// fzf --ansi strips escape sequences from the selected line.
+	line := regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(strings.TrimSuffix(formatLines([]PullRequest{pr}, layout), "\n"), "")
+	if !strings.Contains(line, "feature…") {
+		t.Fatalf("formatLines() = %q, want a truncated branch", line)
+	}
+	sel, ok, err := parseSelection(line)
+	if err != nil || !ok {
+		t.Fatalf("parseSelection(%q) = %v, %v", line, ok, err)
+	}
+	if want := (selection{repo: "ghes.example.com/o/r", number: 42, ref: "feature/very-long-branch", url: pr.URL}); sel != want {
+		t.Errorf("parseSelection() = %+v, want %+v", sel, want)
+	}
+	local := PullRequest{HeadRefName: "release/x", IsLocal: true}
+	if sel, _, _ := parseSelection(lineKeys(local) + "#0"); !sel.local || sel.ref != "release/x" {
+		t.Errorf("parseSelection() of a local branch row = %+v, want local release/x", sel)
+	}
+	if got := strings.Count(lineKeys(PullRequest{}), "\t"); got != keyFields {
+		t.Errorf("lineKeys() of a header has %d tabs, want %d", got, keyFields)
+	}
+}
+
 func TestFormatLines(t *testing.T) {
 	layout := ColumnLayout{
 		NumWidth:     4,
@@ -123,4 +183,139 @@ func TestFormatLines(t *testing.T) {
 			t.Errorf("formatLines() line count = %d, want %d", count, len(prs))
 		}
 	})
+
+	t.Run("sections", func(t *testing.T) {
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t1", HeadRefName: "b1", Section: "Review requested from you"},
+			{Number: 2, AuthorName: "b", Title: "t2", HeadRefName: "b2", Section: "Review requested from you"},
+			{Number: 3, AuthorName: "c", Title: "t3", HeadRefName: "b3", Section: "Your pull requests"},
+		}
+		ansi := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+		lines := strings.Split(strings.TrimSuffix(ansi.ReplaceAllString(formatLines(prs, layout), ""), "\n"), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("formatLines() = %q, want 2 headers and 3 PRs", lines)
+		}
+		for i, want := range map[int]string{0: "# Review requested from you (2)", 3: "# Your pull requests (1)"} {
+			if lines[i] != want {
+				t.Errorf("line %d = %q, want %q", i, lines[i], want)
+			}
+		}
+
+		layout := layout
+		layout.Keys = true
+		header := strings.SplitN(formatLines(prs, layout), "\n", 2)[0]
+		if _, ok, err := parseSelection(header); ok || err != nil {
+			t.Errorf("header %q should parse as no PR: %v, %v", header, ok, err)
+		}
+	})
+}
+
+func TestChecksText(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks Checks
+		want   string
+		color  string
+	}{
+		{"none", Checks{}, "-", brightBlack},
+		{"passing", Checks{Pass: 5}, "✓ 5/5", green},
+		{"pending", Checks{Pass: 2, Pending: 3}, "* 2/5", yellow},
+		{"failing", Checks{Pass: 1, Fail: 1, Pending: 1}, "X 1/3", red},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checksText(tt.checks); got != tt.want {
+				t.Errorf("checksText() = %q, want %q", got, tt.want)
+			}
+			if got := formatChecks(tt.checks, 8); !strings.HasPrefix(got, tt.color) {
+				t.Errorf("formatChecks() = %q, want color %q", got, tt.color)
+			}
+		})
+	}
+}
+
+func TestReviewText(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   PullRequest
+		want string
+	}{
+		{"none", PullRequest{}, ""},
+		{"approved", PullRequest{ReviewDecision: "APPROVED", LatestReviews: []Review{
+			{Author: "alice", State: "APPROVED"}, {Author: "bob", State: "APPROVED"},
+		}}, "✔ 2"},
+		{"approved_without_protection", PullRequest{LatestReviews: []Review{
+			{Author: "alice", State: "APPROVED"}, {Author: "bob", State: "COMMENTED"},
+		}}, "✔ 1"},
+		{"changes_requested", PullRequest{ReviewDecision: "CHANGES_REQUESTED", LatestReviews: []Review{
+			{Author: "alice", State: "CHANGES_REQUESTED"}, {Author: "bob", State: "APPROVED"},
+		}}, "✖ alice"},
+		{"changes_requested_no_reviews", PullRequest{ReviewDecision: "CHANGES_REQUESTED"}, "✖"},
+		{"requested", PullRequest{ReviewDecision: "REVIEW_REQUIRED", ReviewRequests: []string{"bob", "core"}}, "◌ bob,core"},
+		{"required", PullRequest{ReviewDecision: "REVIEW_REQUIRED"}, "◌"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reviewText(tt.pr); got != tt.want {
+				t.Errorf("reviewText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeText(t *testing.T) {
+	tests := []struct {
+		name      string
+		pr        PullRequest
+		want      string
+		mergeable bool
+	}{
+		{"unreported", PullRequest{}, "", false},
+		{"conflicting", PullRequest{Mergeable: "CONFLICTING", MergeStateStatus: "DIRTY"}, "✖", false},
+		{"conflicting_unknown_state", PullRequest{Mergeable: "CONFLICTING", MergeStateStatus: "UNKNOWN"}, "✖", false},
+		{"behind", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "BEHIND"}, "⇣", false},
+		{"blocked", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "BLOCKED"}, "⊘", false},
+		{"unstable", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "UNSTABLE"}, "⚠", true},
+		{"clean", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "CLEAN"}, "✔", true},
+		{"has_hooks", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "HAS_HOOKS"}, "✔", true},
+		{"draft", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "DRAFT"}, "-", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeText(tt.pr); got != tt.want {
+				t.Errorf("mergeText() = %q, want %q", got, tt.want)
+			}
+			if got := isMergeable(tt.pr); got != tt.mergeable {
+				t.Errorf("isMergeable() = %v, want %v", got, tt.mergeable)
+			}
+		})
+	}
+}
+
+func TestCommentsText(t *testing.T) {
+	tests := []struct {
+		name  string
+		pr    PullRequest
+		want  string
+		color string
+	}{
+		{"none", PullRequest{}, "", brightBlack},
+		{"comments_only", PullRequest{Comments: 3}, "3c 0/0t", ""},
+		{"resolved", PullRequest{Comments: 1, ReviewThreads: 4}, "1c 0/4t", ""},
+		{"unresolved", PullRequest{ReviewThreads: 5, UnresolvedThreads: 2}, "0c 2/5t", yellow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commentsText(tt.pr); got != tt.want {
+				t.Errorf("commentsText() = %q, want %q", got, tt.want)
+			}
+			got := formatComments(tt.pr, 8)
+			if tt.color != "" && !strings.HasPrefix(got, tt.color) {
+				t.Errorf("formatComments() = %q, want color %q", got, tt.color)
+			}
+			if tt.color == "" && strings.Contains(got, "\x1b") {
+				t.Errorf("formatComments() = %q, want no color", got)
+			}
+		})
+	}
 }
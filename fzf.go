@@ -1,15 +1,97 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
-	"regexp"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-var selectionRe = regexp.MustCompile(`^#(\d+).*\s+(\S+)\s+\+\s*\d+/-\s*\d+`)
+// Keys that act on the selected PR besides Enter, as reported by fzf's
+// --expect.
+const (
+	keyWeb   = "ctrl-o"
+	keyDiff  = "ctrl-d"
+	keyCopy  = "ctrl-y"
+	keyReady = "ctrl-r"
+)
+
+// keyHeader describes the key bindings in fzf's header.
+func keyHeader(opt options) string {
+	enter := "checkout"
+	if opt.web {
+		enter = "web"
+	}
+	header := "enter: " + enter + "  " + keyWeb + ": web  " + keyDiff + ": diff  " + keyCopy + ": copy URL  " + keyReady + ": ready"
+	if filterKeys() {
+		header += "\n" + keyAllStates + ": all states  " + keyMine + ": mine  " + keyHideDrafts + ": hide drafts"
+	}
+	if opt.multi {
+		header += "\ntab: select  enter: print numbers of several  " +
+			keyLabel + ": label  " + keyReviewer + ": reviewer  " + keyApprove + ": approve"
+	}
+	return header
+}
+
+// expectKeys are the keys passed to fzf's --expect.
+func expectKeys(opt options) []string {
+	keys := []string{keyWeb, keyDiff, keyCopy, keyReady}
+	if opt.multi {
+		keys = append(keys, batchKeys...)
+	}
+	return keys
+}
+
+// fzfVersion is the major and minor version of the installed fzf, or 0, 0
+// when it cannot be told.
+var fzfVersion = sync.OnceValues(func() (int, int) {
+	out, err := exec.Command("fzf", "--version").Output()
+	if err != nil {
+		return 0, 0
+	}
+	return parseFzfVersion(string(out))
+})
+
+// parseFzfVersion reads the output of fzf --version, such as
+// "0.44.1 (debian)" or "0.56.3 (brew)".
+func parseFzfVersion(out string) (major, minor int) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return 0, 0
+	}
+	parts := strings.SplitN(fields[0], ".", 3)
+	if len(parts) < 2 {
+		return 0, 0
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return 0, 0
+	}
+	return major, minor
+}
+
+// fzfAtLeast reports whether the installed fzf is version major.minor or
+// later. Options newer fzf added make older versions exit at startup.
+func fzfAtLeast(major, minor int) bool {
+	ma, mi := fzfVersion()
+	return ma > major || ma == major && mi >= minor
+}
+
+// filterKeys reports whether fzf supports the filter key bindings, whose
+// transform-prompt action came in fzf 0.45.
+func filterKeys() bool {
+	return fzfAtLeast(0, 45)
+}
 
 func switchBack() error {
 	for _, args := range [][]string{
@@ -27,17 +109,42 @@ func switchBack() error {
 	return nil
 }
 
-func runFzf(lines string, opt options) error {
-	args := []string{"--ansi"}
+// refreshResult carries freshly rendered lines, or the error that prevented
+// fetching them, to a running fzf.
+type refreshResult struct {
+	lines string
+	err   error
+}
 
-	// Merge user fzf options, avoiding duplicate --ansi
-	if opt.fzfOptions != "" {
-		extra := opt.fzfOptions
-		if strings.Contains(extra, "--ansi") {
-			extra = strings.ReplaceAll(extra, "--ansi", "")
+// runFzf shows lines in fzf and handles the selection. seen is the seen
+// state from before this run, for the lists the filter keys reload.
+func runFzf(lines string, opt options, seen seenPRs, refresh <-chan refreshResult) error {
+	args, err := fzfArgs(opt)
+	if err != nil {
+		return err
+	}
+
+	state := ""
+	if exe, err := os.Executable(); err == nil && filterKeys() {
+		if f, err := os.CreateTemp("", "gh-list-pr-filters-*.json"); err == nil {
+			f.Close()
+			defer os.Remove(f.Name())
+			if err := saveListState(f.Name(), listState{Args: os.Args[1:], Seen: seen}); err == nil {
+				state = f.Name()
+				args = append(args, filterArgs(exe, state)...)
+			}
 		}
-		if fields := strings.Fields(extra); len(fields) > 0 {
-			args = append(args, fields...)
+	}
+
+	if refresh != nil {
+		f, err := os.CreateTemp("", "gh-list-pr-*.txt")
+		if err == nil {
+			f.Close()
+			defer os.Remove(f.Name())
+			if port, err := freePort(); err == nil {
+				args = append(args, fmt.Sprintf("--listen=%d", port))
+				go reloadFzf(port, f.Name(), state, keyHeader(opt), refresh)
+			}
 		}
 	}
 
@@ -50,25 +157,191 @@ func runFzf(lines string, opt options) error {
 		return fmt.Errorf("cancelled")
 	}
 
-	selected := strings.TrimSpace(string(out))
-	return handleSelection(selected, opt)
+	key, selected := parseFzfOutput(string(out))
+	return handleSelection(key, selected, opt)
+}
+
+// parseFzfOutput splits the output of fzf --expect into the key pressed,
+// empty for Enter, and the selected lines.
+func parseFzfOutput(out string) (key string, selected []string) {
+	key, rest, _ := strings.Cut(out, "\n")
+	for _, line := range strings.Split(rest, "\n") {
+		// Leading tabs separate empty key fields, so keep them.
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			selected = append(selected, line)
+		}
+	}
+	return key, selected
+}
+
+func fzfArgs(opt options) ([]string, error) {
+	args := []string{
+		"--ansi",
+		// Show the line without the hidden key fields; see lineKeys.
+		"--delimiter=\t",
+		fmt.Sprintf("--with-nth=%d..", keyFields+1),
+		"--expect=" + strings.Join(expectKeys(opt), ","),
+		"--header=" + keyHeader(opt),
+	}
+	if opt.multi {
+		args = append(args, "--multi")
+	}
+	args = append(args, previewArgs(opt)...)
+
+	// Merge user fzf options, avoiding duplicate --ansi
+	extra, err := splitShellWords(opt.fzfOptions)
+	if err != nil {
+		return nil, fmt.Errorf("fzf options: %w", err)
+	}
+	for _, arg := range extra {
+		if arg != "--ansi" {
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
+
+// previewArgs are the fzf options showing the PR under the cursor with the
+// __preview subcommand, or nil without --preview.
+func previewArgs(opt options) []string {
+	if !opt.preview {
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	cmd := shellQuote(exe) + " __preview"
+	if opt.remote != "" {
+		cmd += " --remote " + shellQuote(opt.remote)
+	}
+	return []string{"--preview", cmd + " {1}#{2}", "--preview-window", opt.previewWindow}
+}
+
+// reloadFzf waits for the refreshed list and replaces fzf's items through
+// its --listen server, unless a filter toggled in the meantime has reloaded
+// them already. A failed refresh is shown below header, the key help.
+func reloadFzf(port int, path, state, header string, refresh <-chan refreshResult) {
+	r := <-refresh
+	if loadListState(state).Filters != (listFilters{}) {
+		return
+	}
+	action := "reload-sync(" + catCommand(path) + ")"
+	if r.err != nil {
+		action = "change-header:" + header + "\nFailed to refresh, showing cached PRs: " + strings.ReplaceAll(r.err.Error(), "\n", " ")
+	} else if err := os.WriteFile(path, []byte(r.lines), 0o600); err != nil {
+		return
+	}
+
+	// fzf may not be listening yet when the fetch finishes quickly.
+	url := fmt.Sprintf("http://localhost:%d", port)
+	for i := 0; i < 50; i++ {
+		resp, err := http.Post(url, "text/plain", strings.NewReader(action))
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
 }
 
-func handleSelection(selected string, opt options) error {
-	m := selectionRe.FindStringSubmatch(selected)
-	if m == nil {
-		return fmt.Errorf("failed to parse selection: %s", selected)
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
 	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
 
-	num, _ := strconv.Atoi(m[1])
-	ref := m[2]
+// selection is a PR, or a branch with number 0, picked in fzf.
+type selection struct {
+	repo   string // HOST/OWNER/REPO, empty for branches
+	number int
+	ref    string
+	url    string
+	local  bool // a local branch row
+}
+
+// parseSelection reads the hidden key fields of a line picked in fzf. ok is
+// false for section headers.
+func parseSelection(line string) (sel selection, ok bool, err error) {
+	fields := strings.SplitN(line, "\t", keyFields+1)
+	if len(fields) <= keyFields {
+		return selection{}, false, fmt.Errorf("failed to parse selection: %s", line)
+	}
+	if fields[1] == "" {
+		return selection{}, false, nil
+	}
+	num, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return selection{}, false, fmt.Errorf("failed to parse selection: %s", line)
+	}
+	return selection{repo: fields[0], number: num, ref: fields[2], url: fields[3], local: fields[4] != ""}, true, nil
+}
+
+// label names s in messages: "#42" for a PR of the current repository,
+// "HOST/OWNER/REPO#42" for a PR of another one, and the branch of a branch
+// row.
+func (s selection) label(other bool) string {
+	switch {
+	case s.number == 0:
+		return s.ref
+	case other:
+		return s.repo + "#" + strconv.Itoa(s.number)
+	}
+	return "#" + strconv.Itoa(s.number)
+}
+
+// handleSelection runs the action bound to key on the selected lines.
+// Several lines, or a key that only makes sense for a batch, run the
+// action on each PR in turn.
+func handleSelection(key string, lines []string, opt options) error {
+	var sels []selection
+	for _, line := range lines {
+		sel, ok, err := parseSelection(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			sels = append(sels, sel)
+		}
+	}
+	if len(sels) == 0 {
+		return nil
+	}
+	if len(sels) > 1 || slices.Contains(batchKeys, key) {
+		return runBatch(key, sels, opt)
+	}
+	return handleOne(key, sels[0], opt)
+}
 
-	if num == 0 {
-		for _, args := range [][]string{
-			{"git", "checkout", ref},
-			{"git", "pull", "origin", ref},
-			{"git", "submodule", "update", "--init", "--recursive"},
-		} {
+// handleOne runs the action bound to key on a single PR or branch,
+// replacing this process with gh where it can.
+func handleOne(key string, sel selection, opt options) error {
+	ctx := context.Background()
+	ref := sel.ref
+	if sel.number == 0 {
+		if key != "" {
+			return fmt.Errorf("%s has no pull request", ref)
+		}
+		base, err := baseRemote(ctx, opt.remote)
+		if err != nil {
+			return err
+		}
+		// In a fork, several remotes may have the branch, so track the
+		// base remote explicitly when there is no local branch yet.
+		checkout := []string{"git", "checkout", ref}
+		if exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+ref).Run() != nil {
+			checkout = []string{"git", "checkout", "--track", "-b", ref, base.Name + "/" + ref}
+		}
+		steps := [][]string{checkout}
+		// Local branch rows are only switched to, never pulled.
+		if !sel.local {
+			steps = append(steps, []string{"git", "pull", base.Name, ref})
+		}
+		steps = append(steps, []string{"git", "submodule", "update", "--init", "--recursive"})
+		for _, args := range steps {
 			cmd := exec.Command(args[0], args[1:]...)
 			cmd.Stdin = os.Stdin
 			cmd.Stdout = os.Stdout
@@ -79,8 +352,60 @@ func handleSelection(selected string, opt options) error {
 		}
 		return nil
 	}
+
+	repoFlag, other := ghRepoFlag(ctx, opt, sel.repo)
+	number := strconv.Itoa(sel.number)
+	gh := func(args ...string) error {
+		return execCommand("gh", append(append(args, repoFlag...), number)...)
+	}
+
+	switch key {
+	case keyWeb:
+		return gh("pr", "view", "-w")
+	case keyDiff:
+		return gh("pr", "diff")
+	case keyReady:
+		return gh("pr", "ready")
+	case keyCopy:
+		copyToClipboard(sel.url)
+		return nil
+	}
+	if other {
+		// A PR of another repository cannot be checked out here.
+		if !opt.web {
+			fmt.Fprintf(os.Stderr, "%s is not in the current repository; opening it in the browser.\n", sel.label(true))
+			fmt.Fprintf(os.Stderr, "To check it out: gh repo clone %s && cd %s && gh co %s\n", sel.repo, path.Base(sel.repo), number)
+		}
+		return gh("pr", "view", "-w")
+	}
 	if opt.web {
-		return execCommand("gh", "pr", "view", "-w", m[1])
+		return gh("pr", "view", "-w")
+	}
+	return gh("co", "--recurse-submodules")
+}
+
+// ghRepoFlag returns the -R flag pinning gh to the repository of a PR, so
+// that it does not pick the fork, and whether that repository is not the
+// current one.
+func ghRepoFlag(ctx context.Context, opt options, repo string) ([]string, bool) {
+	current, err := currentRepo(ctx, opt.remote)
+	if repo != "" && (err != nil || !strings.EqualFold(repo, repoArg(current))) {
+		return []string{"-R", repo}, true
 	}
-	return execCommand("gh", "co", "--recurse-submodules", m[1])
+	if err != nil {
+		return nil, false
+	}
+	return []string{"-R", repoArg(current)}, false
+}
+
+// copyToClipboard copies text to the clipboard of the terminal, which also
+// works over SSH, with an OSC 52 escape sequence.
+func copyToClipboard(text string) {
+	fmt.Fprint(os.Stderr, osc52(text))
+	fmt.Fprintf(os.Stderr, "Copied %s\n", text)
+}
+
+// osc52 returns the escape sequence that sets the terminal clipboard to s.
+func osc52(s string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(s)) + "\a"
 }
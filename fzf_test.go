@@ -1,88 +1,246 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
-func TestSelectionRegex(t *testing.T) {
+func TestParseSelection(t *testing.T) {
 	tests := []struct {
-		name       string
-		input      string
-		wantMatch  bool
-		wantNum    string
-		wantBranch string
+		name    string
+		input   string
+		want    selection
+		wantOK  bool
+		wantErr bool
 	}{
 		{
-			name:       "standard_pr",
-			input:      "#42  user  Fix bug  feature-branch  +10/-5",
-			wantMatch:  true,
-			wantNum:    "42",
-			wantBranch: "feature-branch",
-		},
-		{
-			name:       "default_branch",
-			input:      "#0  system  main  main  +0/-0",
-			wantMatch:  true,
-			wantNum:    "0",
-			wantBranch: "main",
-		},
-		{
-			name:       "large_pr_number",
-			input:      "#12345  user  Some title  my-branch  +100/-200",
-			wantMatch:  true,
-			wantNum:    "12345",
-			wantBranch: "my-branch",
-		},
-		{
-			name:      "empty_string",
-			input:     "",
-			wantMatch: false,
-		},
-		{
-			name:      "invalid_string",
-			input:     "not a pr line",
-			wantMatch: false,
+			name:   "standard_pr",
+			input:  "o/r\t42\tfeature-branch\thttps://github.com/o/r/pull/42\t\t#42  user  Fix bug  feature-b…  +10/-5",
+			want:   selection{repo: "o/r", number: 42, ref: "feature-branch", url: "https://github.com/o/r/pull/42"},
+			wantOK: true,
 		},
 		{
-			name:       "no_ansi",
-			input:      "#7  user  Title  branch-name  +1/-0",
-			wantMatch:  true,
-			wantNum:    "7",
-			wantBranch: "branch-name",
+			// The visible line no longer matters.
+			name:   "any_visible_line",
+			input:  "o/r\t7\tfix/login\thttps://github.com/o/r/pull/7\t\tanything\twith tabs",
+			want:   selection{repo: "o/r", number: 7, ref: "fix/login", url: "https://github.com/o/r/pull/7"},
+			wantOK: true,
 		},
 		{
-			name:       "padded_deletions",
-			input:      "#34473  lewis6991  feat(lua): add vim.async  vimasync  +2545/-   0",
-			wantMatch:  true,
-			wantNum:    "34473",
-			wantBranch: "vimasync",
+			name:   "branch",
+			input:  "\t0\trelease/1.0\t\t\t#0  -  release/1.0",
+			want:   selection{ref: "release/1.0"},
+			wantOK: true,
 		},
 		{
-			name:       "padded_additions",
-			input:      "#100  user  Title  branch  +   5/-300",
-			wantMatch:  true,
-			wantNum:    "100",
-			wantBranch: "branch",
+			name:   "local_branch",
+			input:  "\t0\trelease/x\t\tlocal\t#0  local  release/x",
+			want:   selection{ref: "release/x", local: true},
+			wantOK: true,
 		},
+		{name: "section_header", input: "\t\t\t\t\t# Review requested (2)"},
+		{name: "no_keys", input: "#42  user  Fix bug  feature-branch  +10/-5", wantErr: true},
+		{name: "bad_number", input: "o/r\tx\tb\t\t\t#x", wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := selectionRe.FindStringSubmatch(tt.input)
-			if tt.wantMatch {
-				if m == nil {
-					t.Fatalf("expected match for %q, got nil", tt.input)
+			got, ok, err := parseSelection(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSelection(%q) error = nil, want error", tt.input)
 				}
-				if m[1] != tt.wantNum {
-					t.Errorf("PR number = %q, want %q", m[1], tt.wantNum)
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSelection(%q) error = %v", tt.input, err)
+			}
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseSelection(%q) = %+v, %v, want %+v, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGhRepoFlag(t *testing.T) {
+	git := initTestRepo(t)
+	git(nil, "remote", "add", "origin", "https://github.com/o/r.git")
+	t.Setenv("GH_REPO", "")
+
+	tests := []struct {
+		repo      string
+		want      []string
+		wantOther bool
+	}{
+		{"github.com/o/r", []string{"-R", "github.com/o/r"}, false},
+		{"", []string{"-R", "github.com/o/r"}, false},
+		{"ghes.example.com/o/r", []string{"-R", "ghes.example.com/o/r"}, true},
+		{"ghes.example.com/acme/app", []string{"-R", "ghes.example.com/acme/app"}, true},
+	}
+	for _, tt := range tests {
+		got, other := ghRepoFlag(context.Background(), options{}, tt.repo)
+		if !slices.Equal(got, tt.want) || other != tt.wantOther {
+			t.Errorf("ghRepoFlag(%q) = %q, %v, want %q, %v", tt.repo, got, other, tt.want, tt.wantOther)
+		}
+	}
+}
+
+func TestParseFzfVersion(t *testing.T) {
+	tests := []struct {
+		out          string
+		major, minor int
+	}{
+		{"0.44.1 (debian)\n", 0, 44},
+		{"0.56.3 (brew)", 0, 56},
+		{"0.35.0\n", 0, 35},
+		{"1.0 (devel)", 1, 0},
+		{"", 0, 0},
+		{"fzf: unknown", 0, 0},
+	}
+	for _, tt := range tests {
+		if major, minor := parseFzfVersion(tt.out); major != tt.major || minor != tt.minor {
+			t.Errorf("parseFzfVersion(%q) = %d.%d, want %d.%d", tt.out, major, minor, tt.major, tt.minor)
+		}
+	}
+}
+
+func TestReloadFzf(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     refreshResult
+		wantPrefix string
+		wantFile   string
+	}{
+		{"reload", refreshResult{lines: "#1  fresh\n"}, "reload-sync(", "#1  fresh\n"},
+		{"error", refreshResult{err: errors.New("offline")},
+			"change-header:enter: checkout\nFailed to refresh, showing cached PRs: offline", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions := make(chan string, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				actions <- string(body)
+			}))
+			defer srv.Close()
+			port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+			path := filepath.Join(t.TempDir(), "lines.txt")
+			refresh := make(chan refreshResult, 1)
+			refresh <- tt.result
+			reloadFzf(port, path, "", "enter: checkout", refresh)
+
+			action := <-actions
+			if !strings.HasPrefix(action, tt.wantPrefix) {
+				t.Errorf("action = %q, want prefix %q", action, tt.wantPrefix)
+			}
+			if tt.wantFile != "" {
+				if !strings.Contains(action, path) {
+					t.Errorf("action = %q, want it to read %q", action, path)
 				}
-				if m[2] != tt.wantBranch {
-					t.Errorf("branch = %q, want %q", m[2], tt.wantBranch)
+				data, err := os.ReadFile(path)
+				if err != nil || string(data) != tt.wantFile {
+					t.Errorf("refreshed file = %q, %v, want %q", data, err, tt.wantFile)
 				}
-			} else {
-				if m != nil {
-					t.Errorf("expected no match for %q, got %v", tt.input, m)
+			}
+		})
+	}
+}
+
+func TestFzfArgs(t *testing.T) {
+	keys := []string{"--delimiter=\t", "--with-nth=6..", "--expect=ctrl-o,ctrl-d,ctrl-y,ctrl-r", "--header=" + keyHeader(options{})}
+	withKeys := func(args ...string) []string {
+		return append(append([]string{"--ansi"}, keys...), args...)
+	}
+	tests := []struct {
+		name    string
+		opts    string
+		want    []string
+		wantErr bool
+	}{
+		{"none", "", withKeys(), false},
+		{"duplicate_ansi", "--ansi --height=50%", withKeys("--height=50%"), false},
+		{"ansi_prefix_kept", "--no-ansi-foo", withKeys("--no-ansi-foo"), false},
+		{"quoted_header", `--header 'my header'`, withKeys("--header", "my header"), false},
+		{"unbalanced", `--header "oops`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fzfArgs(options{fzfOptions: tt.opts})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("fzfArgs(%q) = %q, want error", tt.opts, got)
 				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fzfArgs(%q) error = %v", tt.opts, err)
+			}
+			if strings.Join(got, "\x00") != strings.Join(tt.want, "\x00") {
+				t.Errorf("fzfArgs(%q) = %q, want %q", tt.opts, got, tt.want)
 			}
 		})
 	}
+
+	t.Run("preview", func(t *testing.T) {
+		got, err := fzfArgs(options{preview: true, previewWindow: "left,40%", remote: "up stream", fzfOptions: "--height=50%"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = got[1+len(keys):]
+		if len(got) != 5 || got[0] != "--preview" || got[2] != "--preview-window" || got[3] != "left,40%" || got[4] != "--height=50%" {
+			t.Fatalf("fzfArgs() = %q, want --ansi, the keys, the preview options and then the user's", got)
+		}
+		if want := " __preview --remote " + shellQuote("up stream") + " {1}#{2}"; !strings.HasSuffix(got[1], want) {
+			t.Errorf("--preview %q, want suffix %q", got[1], want)
+		}
+	})
+}
+
+func TestKeyHeader(t *testing.T) {
+	if got := keyHeader(options{}); !strings.HasPrefix(got, "enter: checkout  ctrl-o: web") {
+		t.Errorf("keyHeader() = %q", got)
+	}
+	if got := keyHeader(options{web: true}); !strings.HasPrefix(got, "enter: web  ") {
+		t.Errorf("keyHeader(web) = %q", got)
+	}
+	if got := keyHeader(options{multi: true}); !strings.Contains(got, "ctrl-l: label") {
+		t.Errorf("keyHeader(multi) = %q", got)
+	}
+	if got, want := expectKeys(options{multi: true}), 7; len(got) != want {
+		t.Errorf("expectKeys(multi) = %q, want %d keys", got, want)
+	}
+}
+
+func TestParseFzfOutput(t *testing.T) {
+	tests := []struct {
+		out, key string
+		selected []string
+	}{
+		{"\n#42  alice  title\n", "", []string{"#42  alice  title"}},
+		{"ctrl-y\n#42  alice  title\n", "ctrl-y", []string{"#42  alice  title"}},
+		{"ctrl-l\n#42  a\no/r#7  b\n", "ctrl-l", []string{"#42  a", "o/r#7  b"}},
+		{"ctrl-o\n", "ctrl-o", nil},
+		{"\n\t0\tmain\t\t\t#0  main\r\n", "", []string{"\t0\tmain\t\t\t#0  main"}},
+	}
+	for _, tt := range tests {
+		key, selected := parseFzfOutput(tt.out)
+		if key != tt.key || !slices.Equal(selected, tt.selected) {
+			t.Errorf("parseFzfOutput(%q) = %q, %q, want %q, %q", tt.out, key, selected, tt.key, tt.selected)
+		}
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	if got, want := osc52("https://github.com/o/r/pull/1"), "\x1b]52;c;aHR0cHM6Ly9naXRodWIuY29tL28vci9wdWxsLzE=\a"; got != want {
+		t.Errorf("osc52() = %q, want %q", got, want)
+	}
 }
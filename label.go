@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"` // hex without '#', e.g. "d73a4a"
+}
+
+type colorMode int
+
+const (
+	color16 colorMode = iota
+	color256
+	colorTrue
+)
+
+// labelColorMode guesses the terminal's color support from COLORTERM and
+// TERM, the same variables fzf and most CLIs look at.
+func labelColorMode() colorMode {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return colorTrue
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return color256
+	}
+	return color16
+}
+
+// labelsText is the plain text of the label chips: " name " per label,
+// separated by a space.
+func labelsText(labels []Label) string {
+	chips := make([]string, len(labels))
+	for i, l := range labels {
+		chips[i] = " " + l.Name + " "
+	}
+	return strings.Join(chips, " ")
+}
+
+// formatLabels renders labels as chips in their GitHub colors, padded or
+// truncated to width.
+func formatLabels(labels []Label, width int) string {
+	if len(labels) == 0 {
+		return brightBlack + truncatePad("-", width) + reset
+	}
+	mode := labelColorMode()
+	var b strings.Builder
+	used := 0
+	for i, l := range labels {
+		if i > 0 {
+			if used+1 >= width {
+				break
+			}
+			b.WriteString(" ")
+			used++
+		}
+		chip := " " + l.Name + " "
+		w := displayWidth(chip)
+		if used+w > width {
+			chip = truncatePad(chip, width-used)
+			w = width - used
+		}
+		r, g, bl := parseHexColor(l.Color)
+		b.WriteString(labelEscape(r, g, bl, mode) + chip + reset)
+		used += w
+		if used >= width {
+			break
+		}
+	}
+	b.WriteString(strings.Repeat(" ", width-used))
+	return b.String()
+}
+
+// labelEscape returns the escape sequence for a chip with background r,g,b
+// and a black or white foreground, whichever reads better.
+func labelEscape(r, g, b int, mode colorMode) string {
+	fg := "97"
+	if (299*r+587*g+114*b)/1000 > 150 {
+		fg = "30"
+	}
+	switch mode {
+	case colorTrue:
+		return fmt.Sprintf("\033[%s;48;2;%d;%d;%dm", fg, r, g, b)
+	case color256:
+		return fmt.Sprintf("\033[%s;48;5;%dm", fg, nearest256(r, g, b))
+	}
+	return fmt.Sprintf("\033[%s;%dm", fg, nearest16(r, g, b))
+}
+
+func parseHexColor(hex string) (r, g, b int) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(hex, "#")) != 6 {
+		return 0xed, 0xed, 0xed // GitHub's default label gray
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+// nearest256 maps a color to the closest entry of the xterm 6x6x6 cube or
+// grayscale ramp.
+func nearest256(r, g, b int) int {
+	levels := []int{0, 95, 135, 175, 215, 255}
+	nearestLevel := func(v int) int {
+		best := 0
+		for i, l := range levels {
+			if colorDistance(v, 0, 0, l, 0, 0) < colorDistance(v, 0, 0, levels[best], 0, 0) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := nearestLevel(r), nearestLevel(g), nearestLevel(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := colorDistance(r, g, b, levels[ri], levels[gi], levels[bi])
+
+	gray := (r + g + b) / 3
+	grayIdx := (gray - 8 + 5) / 10
+	if grayIdx < 0 {
+		grayIdx = 0
+	}
+	if grayIdx > 23 {
+		grayIdx = 23
+	}
+	gv := 8 + 10*grayIdx
+	if colorDistance(r, g, b, gv, gv, gv) < cubeDist {
+		return 232 + grayIdx
+	}
+	return cube
+}
+
+// ansi16 are the xterm default RGB values of the 16 basic colors, in
+// background SGR code order 40-47, 100-107.
+var ansi16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// nearest16 returns the background SGR code of the closest basic color.
+func nearest16(r, g, b int) int {
+	best := 0
+	for i, c := range ansi16 {
+		if colorDistance(r, g, b, c[0], c[1], c[2]) < colorDistance(r, g, b, ansi16[best][0], ansi16[best][1], ansi16[best][2]) {
+			best = i
+		}
+	}
+	if best < 8 {
+		return 40 + best
+	}
+	return 100 + best - 8
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLabelColorMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		colorterm string
+		term      string
+		want      colorMode
+	}{
+		{"truecolor", "truecolor", "xterm-256color", colorTrue},
+		{"24bit", "24bit", "", colorTrue},
+		{"256", "", "xterm-256color", color256},
+		{"basic", "", "xterm", color16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COLORTERM", tt.colorterm)
+			t.Setenv("TERM", tt.term)
+			if got := labelColorMode(); got != tt.want {
+				t.Errorf("labelColorMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+		mode colorMode
+		want string
+	}{
+		{"truecolor_dark", "d73a4a", colorTrue, "\033[97;48;2;215;58;74m"},
+		{"truecolor_light", "a2eeef", colorTrue, "\033[30;48;2;162;238;239m"},
+		{"256_cube", "ff0000", color256, "\033[97;48;5;196m"},
+		{"256_gray", "808080", color256, "\033[97;48;5;244m"},
+		{"16_red", "d73a4a", color16, "\033[97;41m"},
+		{"16_white", "ffffff", color16, "\033[30;107m"},
+		{"invalid_is_gray", "zzz", colorTrue, "\033[30;48;2;237;237;237m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b := parseHexColor(tt.hex)
+			if got := labelEscape(r, g, b, tt.mode); got != tt.want {
+				t.Errorf("labelEscape(%q) = %q, want %q", tt.hex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	t.Setenv("COLORTERM", "truecolor")
+	ansi := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	labels := []Label{{Name: "bug", Color: "d73a4a"}, {Name: "needs-qa", Color: "a2eeef"}}
+
+	tests := []struct {
+		name   string
+		labels []Label
+		width  int
+		want   string
+	}{
+		{"fits", labels, 16, " bug   needs-qa "},
+		{"padded", labels[:1], 8, " bug    "},
+		{"truncated", labels, 10, " bug   ne…"},
+		{"second_dropped", labels, 6, " bug  "},
+		{"empty", nil, 3, "-  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ansi.ReplaceAllString(formatLabels(tt.labels, tt.width), "")
+			if got != tt.want {
+				t.Errorf("formatLabels() = %q, want %q", got, tt.want)
+			}
+			if w := displayWidth(got); w != tt.width {
+				t.Errorf("formatLabels() width = %d, want %d", w, tt.width)
+			}
+		})
+	}
+}
@@ -5,19 +5,37 @@ import (
 )
 
 type ColumnLayout struct {
-	NumWidth  int
-	AddWidth  int
-	DelWidth  int
-	FileWidth int
-
-	TitleWidth      int
-	AuthorWidth     int
-	HeadRefWidth    int
-
-	ShowFiles  bool
-	ShowDate   bool
-	ShowTitle  bool
-	ShowAuthor bool
+	RepoWidth     int
+	NumWidth      int
+	AddWidth      int
+	DelWidth      int
+	FileWidth     int
+	ChecksWidth   int
+	CommentsWidth int
+	DateWidth     int
+
+	TitleWidth   int
+	AuthorWidth  int
+	HeadRefWidth int
+	ReviewWidth  int
+	LabelsWidth  int
+	BaseWidth    int
+
+	ShowRepo     bool
+	ShowFiles    bool
+	ShowChecks   bool
+	ShowMerge    bool
+	ShowReview   bool
+	ShowComments bool
+	ShowLabels   bool
+	ShowBase     bool
+	ShowDate     bool
+	ShowTitle    bool
+	ShowAuthor   bool
+
+	// Keys prepends the hidden fields that fzf selections are parsed from;
+	// see lineKeys.
+	Keys bool
 }
 
 type droppableFixed struct {
@@ -35,11 +53,19 @@ func calculateLayout(prs []PullRequest, opt options) ColumnLayout {
 		return ColumnLayout{NumWidth: 4, ShowFiles: true, ShowDate: true, ShowTitle: true, ShowAuthor: true}
 	}
 
+	maxRepo := 0
 	maxNum := 4
 	maxAdd := 1
 	maxDel := 1
 	maxFile := 1
+	maxChecks := 0
+	maxComments := 0
+	maxDate := 0
+	hasMerge := false
 	for _, pr := range prs {
+		if w := displayWidth(pr.Repository); opt.multiRepo() && w > maxRepo {
+			maxRepo = w
+		}
 		if w := len(fmt.Sprintf("%d", pr.Number)); w > maxNum {
 			maxNum = w
 		}
@@ -52,6 +78,20 @@ func calculateLayout(prs []PullRequest, opt options) ColumnLayout {
 		if w := len(fmt.Sprintf("%d", pr.ChangedFiles)); w > maxFile {
 			maxFile = w
 		}
+		if w := displayWidth(pr.displayDate()); w > maxDate {
+			maxDate = w
+		}
+		if w := displayWidth(commentsText(pr)); w > maxComments {
+			maxComments = w
+		}
+		if mergeText(pr) != "" {
+			hasMerge = true
+		}
+		if pr.Checks.Total() > 0 {
+			if w := displayWidth(checksText(pr.Checks)); w > maxChecks {
+				maxChecks = w
+			}
+		}
 	}
 
 	// Natural widths for variable columns
@@ -59,38 +99,67 @@ func calculateLayout(prs []PullRequest, opt options) ColumnLayout {
 		"authorName":  0,
 		"title":       0,
 		"headRefName": 0,
+		"review":      0,
+		"labels":      0,
+		"baseRefName": 0,
 	}
+	bases := map[string]bool{}
 	for _, pr := range prs {
 		if w := displayWidth(pr.AuthorName); w > natW["authorName"] {
 			natW["authorName"] = w
 		}
-		if w := displayWidth(pr.Title); w > natW["title"] {
+		if w := displayWidth(pr.TreePrefix + pr.Title); w > natW["title"] {
 			natW["title"] = w
 		}
 		if w := displayWidth(pr.HeadRefName); w > natW["headRefName"] {
 			natW["headRefName"] = w
 		}
+		if w := displayWidth(reviewText(pr)); w > natW["review"] {
+			natW["review"] = w
+		}
+		if w := displayWidth(labelsText(pr.Labels)); w > natW["labels"] {
+			natW["labels"] = w
+		}
+		if pr.Number != 0 {
+			bases[pr.BaseRefName] = true
+			if w := displayWidth(pr.BaseRefName); w > natW["baseRefName"] {
+				natW["baseRefName"] = w
+			}
+		}
 	}
 
-	// Required fixed width: "#N  " + "+N/-N"
-	required := (maxNum + 3) + (maxAdd + maxDel + 3)
+	// Required fixed width: "[OWNER/REPO]#N  " + "+N/-N"
+	required := maxRepo + (maxNum + 3) + (maxAdd + maxDel + 3)
 
 	droppableFixedCols := []droppableFixed{
-		{name: "files", width: maxFile + 10}, // "  N files  "
-		{name: "date", width: 22},            // "  " + 20 chars
+		{name: "files", width: maxFile + 10},       // "  N files  "
+		{name: "comments", width: maxComments + 2}, // "  Nc U/Tt"
+		{name: "date", width: maxDate + 2},         // "  " + date
+		{name: "checks", width: maxChecks + 2},     // "  X p/t"
+		{name: "merge", width: 3},                  // "  ✔"
 	}
 
+	// Columns are shrunk in this order until everything fits.
 	variableCols := []variableCol{
+		{name: "review", min: 3},
+		{name: "labels", min: 8},
+		{name: "baseRefName", min: 8},
 		{name: "title", min: 15},
 		{name: "authorName", min: 6},
 		{name: "headRefName", min: 12},
 	}
 
 	show := map[string]bool{
-		"files":      true,
-		"date":       true,
-		"title":      true,
-		"authorName": true,
+		"files":       true,
+		"date":        true,
+		"checks":      maxChecks > 0, // hidden when no PR reports any checks
+		"merge":       hasMerge,
+		"comments":    maxComments > 0, // hidden when no PR has any conversation
+		"review":      natW["review"] > 0,
+		"labels":      natW["labels"] > 0,
+		"baseRefName": len(bases) > 1, // hidden when all PRs share a base
+		"title":       true,
+		"authorName":  true,
 	}
 
 	colW := map[string]int{}
@@ -167,35 +236,45 @@ func calculateLayout(prs []PullRequest, opt options) ColumnLayout {
 		return false
 	}
 
+	visibleVars := func() []variableCol {
+		var cols []variableCol
+		for _, v := range variableCols {
+			if s, ok := show[v.name]; ok && s || !ok {
+				cols = append(cols, v)
+			}
+		}
+		return cols
+	}
+
 	// Phase 1 & 2
-	if !tryFit(computeAvail(), variableCols) {
+	if !tryFit(computeAvail(), visibleVars()) {
 		// Phase 3: set all variable columns to min width
 		for _, v := range variableCols {
 			colW[v.name] = v.min
 		}
 
 		type droppable struct {
-			name     string
-			isFixed  bool
+			name    string
+			isFixed bool
 		}
 		droppableAll := []droppable{
 			{name: "files", isFixed: true},
+			{name: "comments", isFixed: true},
 			{name: "date", isFixed: true},
+			{name: "checks", isFixed: true},
+			{name: "merge", isFixed: true},
+			{name: "review", isFixed: false},
+			{name: "labels", isFixed: false},
+			{name: "baseRefName", isFixed: false},
 			{name: "title", isFixed: false},
 			{name: "authorName", isFixed: false},
 		}
 
 		for _, drop := range droppableAll {
 			// Check if current state fits
-			var visibleVar []variableCol
-			for _, v := range variableCols {
-				if s, ok := show[v.name]; ok && s || !ok {
-					visibleVar = append(visibleVar, v)
-				}
-			}
 			avail := computeAvail()
 			total := 0
-			for _, v := range visibleVar {
+			for _, v := range visibleVars() {
 				total += colW[v.name]
 			}
 			if total <= avail {
@@ -208,27 +287,32 @@ func calculateLayout(prs []PullRequest, opt options) ColumnLayout {
 			}
 
 			// Re-run Phase 2 on remaining visible variable columns
-			visibleVar = nil
-			for _, v := range variableCols {
-				if s, ok := show[v.name]; ok && s || !ok {
-					visibleVar = append(visibleVar, v)
-				}
-			}
-			if tryFit(computeAvail(), visibleVar) {
+			if tryFit(computeAvail(), visibleVars()) {
 				break
 			}
 		}
 	}
 
 	layout := ColumnLayout{
-		NumWidth:  maxNum,
-		AddWidth:  maxAdd,
-		DelWidth:  maxDel,
-		FileWidth: maxFile,
-		ShowFiles: show["files"],
-		ShowDate:  show["date"],
-		ShowTitle: show["title"],
-		ShowAuthor: show["authorName"],
+		RepoWidth:     maxRepo,
+		NumWidth:      maxNum,
+		AddWidth:      maxAdd,
+		DelWidth:      maxDel,
+		FileWidth:     maxFile,
+		ChecksWidth:   maxChecks,
+		CommentsWidth: maxComments,
+		DateWidth:     maxDate,
+		ShowRepo:      maxRepo > 0,
+		ShowFiles:     show["files"],
+		ShowChecks:    show["checks"],
+		ShowMerge:     show["merge"],
+		ShowComments:  show["comments"],
+		ShowReview:    show["review"],
+		ShowLabels:    show["labels"],
+		ShowBase:      show["baseRefName"],
+		ShowDate:      show["date"],
+		ShowTitle:     show["title"],
+		ShowAuthor:    show["authorName"],
 	}
 	if w, ok := colW["title"]; ok {
 		layout.TitleWidth = w
@@ -239,6 +323,15 @@ func calculateLayout(prs []PullRequest, opt options) ColumnLayout {
 	if w, ok := colW["headRefName"]; ok {
 		layout.HeadRefWidth = w
 	}
+	if w, ok := colW["review"]; ok {
+		layout.ReviewWidth = w
+	}
+	if w, ok := colW["labels"]; ok {
+		layout.LabelsWidth = w
+	}
+	if w, ok := colW["baseRefName"]; ok {
+		layout.BaseWidth = w
+	}
 
 	return layout
 }
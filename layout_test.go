@@ -107,4 +107,176 @@ func TestCalculateLayout(t *testing.T) {
 				fzfTotal, printTotal)
 		}
 	})
+
+	t.Run("checks_column", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t", HeadRefName: "b", Checks: Checks{Pass: 10, Fail: 2}},
+			{Number: 2, AuthorName: "a", Title: "t", HeadRefName: "b"},
+		}
+		layout := calculateLayout(prs, options{print: true})
+		if !layout.ShowChecks {
+			t.Error("checks column should be shown when a PR has checks")
+		}
+		if layout.ChecksWidth != displayWidth("X 10/12") {
+			t.Errorf("ChecksWidth = %d, want %d", layout.ChecksWidth, displayWidth("X 10/12"))
+		}
+
+		layout = calculateLayout(prs[1:], options{print: true})
+		if layout.ShowChecks {
+			t.Error("checks column should be hidden when no PR has checks")
+		}
+	})
+
+	t.Run("review_column", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t", HeadRefName: "b", ReviewRequests: []string{"alice"}},
+			{Number: 2, AuthorName: "a", Title: "t", HeadRefName: "b"},
+		}
+		layout := calculateLayout(prs, options{print: true})
+		if !layout.ShowReview || layout.ReviewWidth != displayWidth("◌ alice") {
+			t.Errorf("ShowReview = %v, ReviewWidth = %d, want true, %d",
+				layout.ShowReview, layout.ReviewWidth, displayWidth("◌ alice"))
+		}
+
+		layout = calculateLayout(prs[1:], options{print: true})
+		if layout.ShowReview {
+			t.Error("review column should be hidden when no PR has review state")
+		}
+	})
+
+	t.Run("repo_column", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, Repository: "cli/cli", AuthorName: "a", Title: "t", HeadRefName: "b"},
+			{Number: 2, Repository: "cli/go-gh", AuthorName: "a", Title: "t", HeadRefName: "b"},
+		}
+		layout := calculateLayout(prs, options{print: true})
+		if layout.ShowRepo {
+			t.Error("repo column should be hidden for the current repository")
+		}
+
+		layout = calculateLayout(prs, options{print: true, repos: []string{"cli/cli", "cli/go-gh"}})
+		if !layout.ShowRepo || layout.RepoWidth != len("cli/go-gh") {
+			t.Errorf("ShowRepo = %v, RepoWidth = %d, want true, %d", layout.ShowRepo, layout.RepoWidth, len("cli/go-gh"))
+		}
+	})
+
+	t.Run("date_width", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t", HeadRefName: "b", Date: "3d ago"},
+			{Number: 2, AuthorName: "a", Title: "t", HeadRefName: "c", Date: "11mo ago"},
+		}
+		if layout := calculateLayout(prs, options{print: true}); layout.DateWidth != len("11mo ago") {
+			t.Errorf("DateWidth = %d, want %d", layout.DateWidth, len("11mo ago"))
+		}
+	})
+
+	t.Run("base_column", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t", HeadRefName: "b", BaseRefName: "main"},
+			{Number: 2, AuthorName: "a", Title: "t", HeadRefName: "c", BaseRefName: "release/1.0"},
+			{Number: 0, AuthorName: "system", Title: "main", HeadRefName: "main"},
+		}
+		layout := calculateLayout(prs, options{print: true})
+		if !layout.ShowBase || layout.BaseWidth != len("release/1.0") {
+			t.Errorf("ShowBase = %v, BaseWidth = %d, want true, %d", layout.ShowBase, layout.BaseWidth, len("release/1.0"))
+		}
+
+		prs[1].BaseRefName = "main"
+		if layout := calculateLayout(prs, options{print: true}); layout.ShowBase {
+			t.Error("base column should be hidden when all PRs share a base")
+		}
+	})
+
+	t.Run("tree_prefix", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "Base", HeadRefName: "b"},
+			{Number: 2, AuthorName: "a", Title: "Base", HeadRefName: "c", TreePrefix: "   └─ "},
+		}
+		if layout := calculateLayout(prs, options{print: true}); layout.TitleWidth != displayWidth("   └─ Base") {
+			t.Errorf("TitleWidth = %d, want %d including the tree prefix", layout.TitleWidth, displayWidth("   └─ Base"))
+		}
+	})
+
+	t.Run("comments_column", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t", HeadRefName: "b", Comments: 12, ReviewThreads: 3, UnresolvedThreads: 1},
+			{Number: 2, AuthorName: "a", Title: "t", HeadRefName: "b"},
+		}
+		layout := calculateLayout(prs, options{print: true})
+		if !layout.ShowComments || layout.CommentsWidth != len("12c 1/3t") {
+			t.Errorf("ShowComments = %v, CommentsWidth = %d, want true, %d", layout.ShowComments, layout.CommentsWidth, len("12c 1/3t"))
+		}
+		if layout := calculateLayout(prs[1:], options{print: true}); layout.ShowComments {
+			t.Error("comments column should be hidden when no PR has comments")
+		}
+	})
+
+	t.Run("merge_column", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t", HeadRefName: "b", Mergeable: "CONFLICTING", MergeStateStatus: "DIRTY"},
+			{Number: 0, AuthorName: "system", Title: "main", HeadRefName: "main"},
+		}
+		if layout := calculateLayout(prs, options{print: true}); !layout.ShowMerge {
+			t.Error("merge column should be shown when a PR reports mergeability")
+		}
+		if layout := calculateLayout(prs[1:], options{print: true}); layout.ShowMerge {
+			t.Error("merge column should be hidden for branch rows only")
+		}
+	})
+
+	t.Run("labels_column", func(t *testing.T) {
+		t.Setenv("COLUMNS", "200")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "a", Title: "t", HeadRefName: "b",
+				Labels: []Label{{Name: "bug", Color: "d73a4a"}, {Name: "hotfix", Color: "b60205"}}},
+			{Number: 2, AuthorName: "a", Title: "t", HeadRefName: "b"},
+		}
+		layout := calculateLayout(prs, options{print: true})
+		if want := displayWidth(" bug   hotfix "); !layout.ShowLabels || layout.LabelsWidth != want {
+			t.Errorf("ShowLabels = %v, LabelsWidth = %d, want true, %d", layout.ShowLabels, layout.LabelsWidth, want)
+		}
+
+		layout = calculateLayout(prs[1:], options{print: true})
+		if layout.ShowLabels {
+			t.Error("labels column should be hidden when no PR has labels")
+		}
+
+		// Labels are dropped before the title.
+		t.Setenv("COLUMNS", "40")
+		prs[0].Title = "A title that is long enough to need the space"
+		layout = calculateLayout(prs, options{print: true})
+		if layout.ShowLabels || !layout.ShowTitle {
+			t.Errorf("ShowLabels = %v, ShowTitle = %v, want false, true", layout.ShowLabels, layout.ShowTitle)
+		}
+	})
+
+	t.Run("hidden_base_takes_no_space", func(t *testing.T) {
+		t.Setenv("COLUMNS", "100")
+		prs := []PullRequest{
+			{Number: 1, AuthorName: "alice", HeadRefName: "feature-one", CreatedAt: "2025-01-15T10:00:00Z",
+				Title: "A title that is long enough to be truncated at this width, and then some more"},
+			{Number: 2, AuthorName: "bob", Title: "Short", HeadRefName: "feature-two", CreatedAt: "2025-01-15T10:00:00Z"},
+		}
+		var widths []int
+		for _, base := range []string{"m", "release/2024.10-hotfix-branch"} {
+			prs[0].BaseRefName, prs[1].BaseRefName = base, base
+			layout := calculateLayout(prs, options{print: true})
+			if layout.ShowBase {
+				t.Fatalf("base %q: ShowBase = true, want false when all PRs share it", base)
+			}
+			widths = append(widths, layout.TitleWidth)
+		}
+		if widths[0] != widths[1] {
+			t.Errorf("TitleWidth = %d with a short base, %d with a long one; want the same", widths[0], widths[1])
+		}
+	})
+
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Keys that toggle a filter inside fzf and reload the list.
+const (
+	keyAllStates  = "alt-a"
+	keyMine       = "alt-m"
+	keyHideDrafts = "alt-d"
+)
+
+// listFilters are the filters toggled inside fzf.
+type listFilters struct {
+	AllStates  bool `json:"allStates"`
+	Mine       bool `json:"mine"`
+	HideDrafts bool `json:"hideDrafts"`
+}
+
+// toggle flips the filter bound to key.
+func (f *listFilters) toggle(key string) {
+	switch key {
+	case keyAllStates:
+		f.AllStates = !f.AllStates
+	case keyMine:
+		f.Mine = !f.Mine
+	case keyHideDrafts:
+		f.HideDrafts = !f.HideDrafts
+	}
+}
+
+// prompt is the fzf prompt naming the filters in effect.
+func (f listFilters) prompt() string {
+	var names []string
+	if f.AllStates {
+		names = append(names, "all states")
+	}
+	if f.Mine {
+		names = append(names, "mine")
+	}
+	if f.HideDrafts {
+		names = append(names, "no drafts")
+	}
+	if len(names) == 0 {
+		return "> "
+	}
+	return "[" + strings.Join(names, ", ") + "]> "
+}
+
+// apply adds the filters to the options given on the command line. The
+// filter options come after the search options so that they override the
+// user's.
+func (f listFilters) apply(opt options) options {
+	if f.AllStates {
+		opt.filterOptions += " --state all"
+	}
+	if f.Mine {
+		opt.filterOptions += " --author @me"
+	}
+	opt.hideDrafts = opt.hideDrafts || f.HideDrafts
+	return opt
+}
+
+// listState is what runFzf shares with the __list subcommands run by the
+// key bindings, through a state file.
+type listState struct {
+	// Args are the command line args of this run. They stay out of the
+	// bindings, as fzf would end reload( at a ")" in them and replace
+	// placeholders such as {}.
+	Args    []string    `json:"args"`
+	Filters listFilters `json:"filters"`
+	// Seen is the seen state from before this run. The reloaded lists are
+	// marked unread against it, as main has since recorded their PRs.
+	Seen seenPRs `json:"seen"`
+}
+
+// loadListState reads the state file; a missing or empty one has no
+// filters and nothing seen.
+func loadListState(path string) listState {
+	var st listState
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &st)
+	}
+	return st
+}
+
+func saveListState(path string, st listState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// filterArgs are the fzf options binding the filter keys. Each key toggles
+// its filter and shows the result in the prompt, then reloads the list.
+func filterArgs(exe, state string) []string {
+	list := shellQuote(exe) + " __list --state " + shellQuote(state)
+	var out []string
+	for _, key := range []string{keyAllStates, keyMine, keyHideDrafts} {
+		out = append(out, "--bind",
+			key+":transform-prompt("+list+" --toggle "+key+")+reload("+list+")")
+	}
+	return out
+}
+
+// runList implements the hidden __list subcommand run by the filter key
+// bindings. With --toggle, it flips a filter in the state file and prints
+// the new prompt; otherwise it prints the list for the command line args of
+// the state file with its filters applied.
+func runList(args []string) error {
+	var state, key string
+	fs := pflag.NewFlagSet("__list", pflag.ContinueOnError)
+	fs.StringVar(&state, "state", "", "")
+	fs.StringVar(&key, "toggle", "", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if state == "" {
+		return fmt.Errorf("usage: gh list-pr __list --state FILE [--toggle KEY]")
+	}
+
+	st := loadListState(state)
+	if key != "" {
+		st.Filters.toggle(key)
+		if err := saveListState(state, st); err != nil {
+			return err
+		}
+		fmt.Print(st.Filters.prompt())
+		return nil
+	}
+
+	opt, err := st.options()
+	if err != nil {
+		return err
+	}
+
+	// fzf shows what is printed, so report failures as a line that cannot
+	// be selected.
+	lines, err := listLines(context.Background(), opt, st.Seen)
+	if err != nil {
+		fmt.Printf("%s%sFailed to %s\n", lineKeys(PullRequest{}), sectionHeaderPrefix, strings.ReplaceAll(err.Error(), "\n", " "))
+		return nil
+	}
+	fmt.Print(lines)
+	return nil
+}
+
+// options parses the command line args of the state with its filters
+// applied.
+func (st listState) options() (options, error) {
+	var opt options
+	flags := pflag.NewFlagSet("gh list-pr", pflag.ContinueOnError)
+	defineFlags(flags, &opt)
+	if err := flags.Parse(st.Args); err != nil {
+		return options{}, err
+	}
+	return st.Filters.apply(opt).withSearchRepo(), nil
+}
+
+// listLines fetches and renders the list for opt like main, without the
+// cache and without recording the PRs as seen. PRs are marked unread
+// against seen.
+func listLines(ctx context.Context, opt options, seen seenPRs) (string, error) {
+	if opt.hostname != "" {
+		os.Setenv("GH_HOST", opt.hostname)
+	}
+	targets, err := searchTargets(ctx, opt.repos, opt.org, opt.remote, opt.hostname, opt.search != "")
+	if err != nil {
+		return "", fmt.Errorf("fetch PRs: %w", err)
+	}
+
+	data, err := gatherList(ctx, opt, targets, true)
+	if err != nil {
+		return "", err
+	}
+
+	// Warnings get a line that cannot be selected, as fzf shows stdout only.
+	var b strings.Builder
+	for _, w := range data.warnings {
+		fmt.Fprintf(&b, "%s%sWarning: %s\n", lineKeys(PullRequest{}), sectionHeaderPrefix, strings.ReplaceAll(w, "\n", " "))
+	}
+	markUnread(data.prs, seen)
+	b.WriteString(renderPRs(preparePRs(data.prs, data.branches, data.emoji), opt))
+	return b.String(), nil
+}
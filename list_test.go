@@ -0,0 +1,96 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestListFilters(t *testing.T) {
+	var f listFilters
+	if got := f.prompt(); got != "> " {
+		t.Errorf("prompt() = %q, want %q", got, "> ")
+	}
+	f.toggle(keyAllStates)
+	f.toggle(keyHideDrafts)
+	if got, want := f.prompt(), "[all states, no drafts]> "; got != want {
+		t.Errorf("prompt() = %q, want %q", got, want)
+	}
+
+	opt := f.apply(options{searchOptions: "--state merged"})
+	// The default branch rows stay, as they go only with the user's options.
+	if opt.searchOptions != "--state merged" || opt.filterOptions != " --state all" || !opt.hideDrafts {
+		t.Errorf("apply() = %+v", opt)
+	}
+	s, err := searchFilter(opt.searchOptions+" "+opt.filterOptions, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.query("repo:o/r"), "repo:o/r is:pr sort:created-desc"; got != want {
+		t.Errorf("query() = %q, want %q", got, want)
+	}
+
+	f.toggle(keyAllStates)
+	f.toggle(keyMine)
+	if got := f.apply(options{}); got.searchOptions != "" || got.filterOptions != " --author @me" {
+		t.Errorf("apply() = %+v", got)
+	}
+}
+
+func TestListFiltersState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filters.json")
+	if got := loadListState(path); got.Filters != (listFilters{}) || got.Seen != nil {
+		t.Errorf("loadListState() of a missing file = %+v", got)
+	}
+	seen := seenPRs{"github.com/o/r": {1: {}}}
+	if err := saveListState(path, listState{Seen: seen}); err != nil {
+		t.Fatal(err)
+	}
+	// Each toggle is saved for the reload that follows it.
+	for range 2 {
+		if err := runList([]string{"--state", path, "--toggle", keyMine}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runList([]string{"--state", path, "--toggle", keyHideDrafts}); err != nil {
+		t.Fatal(err)
+	}
+	got := loadListState(path)
+	if want := (listFilters{HideDrafts: true}); got.Filters != want {
+		t.Errorf("loadListState() filters = %+v, want %+v", got.Filters, want)
+	}
+	// The seen state from before the run survives the toggles.
+	if _, ok := got.Seen["github.com/o/r"][1]; !ok {
+		t.Errorf("loadListState() seen = %+v, want %+v", got.Seen, seen)
+	}
+}
+
+func TestFilterArgs(t *testing.T) {
+	got := filterArgs("/bin/gh-list-pr", "/tmp/state.json")
+	if len(got) != 6 {
+		t.Fatalf("filterArgs() = %q, want 3 bindings", got)
+	}
+	want := "alt-m:transform-prompt('/bin/gh-list-pr' __list --state '/tmp/state.json' --toggle alt-m)+" +
+		"reload('/bin/gh-list-pr' __list --state '/tmp/state.json')"
+	if got[2] != "--bind" || got[3] != want {
+		t.Errorf("filterArgs()[2:4] = %q, want --bind %q", got[2:4], want)
+	}
+}
+
+func TestListStateOptions(t *testing.T) {
+	// Args that would end reload( early or hold fzf placeholders reach
+	// __list intact through the state file.
+	fzfOptions := "--bind 'ctrl-x:execute(echo {})' --preview 'cat {1}'"
+	searchOptions := "--search '{q} in:title)'"
+	path := filepath.Join(t.TempDir(), "filters.json")
+	st := listState{Args: []string{"-f", fzfOptions, "-s", searchOptions}, Filters: listFilters{Mine: true}}
+	if err := saveListState(path, st); err != nil {
+		t.Fatal(err)
+	}
+	opt, err := loadListState(path).options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.fzfOptions != fzfOptions || opt.searchOptions != searchOptions || opt.filterOptions != " --author @me" {
+		t.Errorf("options() = %+v", opt)
+	}
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/spf13/pflag"
+	"golang.org/x/sync/errgroup"
 )
 
 type options struct {
@@ -16,27 +22,158 @@ type options struct {
 	web           bool
 	fzfOptions    string
 	version       bool
+	repos         []string
+	org           string
+	refresh       bool
+	noCache       bool
+	remote        string
+	branches      []string
+	local         bool
+	mergeableOnly bool
+	inbox         bool
+	unread        bool
+	hostname      string
+	bases         []string
+	dateMode      string
+	dateField     string
+	search        string
+	preview       bool
+	multi         bool
+	previewWindow string
+
+	// filterOptions and hideDrafts are toggled inside fzf, see runList.
+	// filterOptions are gh pr list flags applied after searchOptions; unlike
+	// those, they keep the default branch rows.
+	filterOptions string
+	hideDrafts    bool
+}
+
+// fetch lists the PRs to show from targets: the inbox sections with
+// --inbox, else the PRs matching the search options.
+func (o options) fetch(ctx context.Context, targets []searchTarget) ([]PullRequest, error) {
+	filter, err := searchFilter(o.searchOptions+" "+o.filterOptions, queryBase(o.bases))
+	if err != nil {
+		return nil, err
+	}
+	filter.search = strings.TrimSpace(o.search + " " + filter.search)
+	if o.inbox {
+		return fetchInbox(ctx, filter, targets)
+	}
+	return fetchPRs(ctx, filter, targets)
+}
+
+// cacheKey identifies the PR list of targets fetched with these options.
+func (o options) cacheKey(targets []searchTarget) string {
+	key := o.searchOptions
+	if o.search != "" {
+		key += "\x00--search\x00" + o.search
+	}
+	if base := queryBase(o.bases); base != "" {
+		key += "\x00--base\x00" + base
+	}
+	if o.inbox {
+		key = "--inbox\x00" + key
+	}
+	return prCacheKey(targets, key)
+}
+
+// queryBase returns the --base pattern when it is a single branch name,
+// which the search query can match, or "" when the bases are only matched
+// locally.
+func queryBase(bases []string) string {
+	if len(bases) != 1 || strings.ContainsAny(bases[0], `*?[\`) {
+		return ""
+	}
+	return bases[0]
+}
+
+// emojiHost is the host whose emoji set titles are rendered with.
+func emojiHost(opt options, targets []searchTarget) string {
+	if opt.hostname != "" {
+		return opt.hostname
+	}
+	if len(targets) > 0 {
+		return targets[0].host
+	}
+	host, _ := auth.DefaultHost()
+	return host
+}
+
+// multiRepo reports whether PRs are listed from explicitly given
+// repositories or a global search rather than the current repository.
+func (o options) multiRepo() bool {
+	return len(o.repos) > 0 || o.org != "" || o.search != ""
+}
+
+// withSearchRepo lists the repository given to gh pr list's -R in
+// --search-options, which gh used to be run with, like --repo. Invalid
+// search options are reported when fetching.
+func (o options) withSearchRepo() options {
+	if f, err := searchFilter(o.searchOptions, ""); err == nil && f.repo != "" {
+		o.repos = append(slices.Clip(o.repos), f.repo)
+	}
+	return o
+}
+
+// defineFlags defines the command line flags, setting opt, on fs.
+func defineFlags(fs *pflag.FlagSet, opt *options) {
+	fs.BoolVarP(&opt.back, "back", "b", false, "Switch to the previous branch (like git switch -)")
+	fs.BoolVarP(&opt.print, "print", "p", false, "Print list without launching fzf selector")
+	fs.StringVarP(&opt.searchOptions, "search-options", "s", "", "Filter PRs with gh pr list flags (--state, --limit, --author, --label, --search, ...; defaults to 30 items, open only)")
+	fs.BoolVarP(&opt.web, "web", "w", false, "Open selected PR in web browser")
+	fs.BoolVarP(&opt.multi, "multi", "m", false, "Select several PRs with Tab and act on all of them")
+	fs.StringVarP(&opt.fzfOptions, "fzf-options", "f", "", "Additional fzf options")
+	fs.BoolVarP(&opt.version, "version", "v", false, "Print version")
+	fs.StringArrayVarP(&opt.repos, "repo", "R", nil, "List PRs of `[HOST/]OWNER/REPO` instead of the current repository (repeatable)")
+	fs.StringVar(&opt.search, "search", "", "List PRs matching a GitHub search `query` across all repositories (or within --repo/--org)")
+	fs.StringVar(&opt.org, "org", "", "List PRs of all repositories in the `organization`")
+	fs.StringVar(&opt.hostname, "hostname", "", "GitHub `host` for --org, --repo without a host, emojis and gh (default: the current repository's host)")
+	fs.StringVar(&opt.remote, "remote", "", "Git `remote` of the base repository (default: gh-list-pr.remote git config, else resolved like gh)")
+	fs.StringArrayVar(&opt.branches, "branch", nil, "Default branch `glob` to list besides the remote's HEAD (repeatable; default: gh-list-pr.branch git config, main/master/develop/staging)")
+	fs.BoolVarP(&opt.local, "local", "l", false, "Also list local branches that have no PR")
+	fs.BoolVarP(&opt.inbox, "inbox", "i", false, "List PRs awaiting your review, your own PRs and PRs mentioning you, in sections")
+	fs.StringArrayVar(&opt.bases, "base", nil, "Only list PRs into base branches matching `glob` (repeatable)")
+	fs.StringVar(&opt.dateMode, "date", "iso", "Date `format`: iso, local (local time zone) or relative (e.g. 3d ago)")
+	fs.StringVar(&opt.dateField, "date-field", "created", "Date to show: `created`, updated or merged")
+	fs.BoolVarP(&opt.unread, "unread", "u", false, "Only list PRs updated since they were last listed")
+	fs.BoolVar(&opt.mergeableOnly, "mergeable-only", false, "Only list PRs that can be merged without conflicts or blockers")
+	fs.BoolVar(&opt.preview, "preview", false, "Show the description, checks and changed files of the PR under the cursor")
+	fs.StringVar(&opt.previewWindow, "preview-window", "right,50%", "fzf --preview-window `layout` used with --preview")
+	fs.BoolVar(&opt.refresh, "refresh", false, "Wait for fresh data instead of showing the cached PR list first")
+	fs.BoolVar(&opt.noCache, "no-cache", false, "Neither read nor write the PR list cache")
 }
 
 func main() {
+	// Hidden subcommands run by fzf.
+	if len(os.Args) > 1 && (os.Args[1] == "__preview" || os.Args[1] == "__list") {
+		run := runPreview
+		if os.Args[1] == "__list" {
+			run = runList
+		}
+		if err := run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var opt options
-	pflag.BoolVarP(&opt.back, "back", "b", false, "Switch to the previous branch (like git switch -)")
-	pflag.BoolVarP(&opt.print, "print", "p", false, "Print list without launching fzf selector")
-	pflag.StringVarP(&opt.searchOptions, "search-options", "s", "", "Filter PRs (passed to gh pr list; defaults to 30 items, open only)")
-	pflag.BoolVarP(&opt.web, "web", "w", false, "Open selected PR in web browser")
-	pflag.StringVarP(&opt.fzfOptions, "fzf-options", "f", "", "Additional fzf options")
-	pflag.BoolVarP(&opt.version, "version", "v", false, "Print version")
+	defineFlags(pflag.CommandLine, &opt)
 
 	pflag.Usage = func() {
 		fmt.Fprintln(os.Stderr, `List pull requests and interactively select one to checkout using fzf.
 
 Shows a color-coded PR list with author, title, branch, additions/deletions,
-changed files, and date. Default branches (main/master/develop/staging) are
-included when no search filter is applied.
+CI checks, changed files, and date. Default branches (the remote's HEAD and
+main/master/develop/staging, or the --branch patterns) are included when no
+search filter is applied.
 
 USAGE
   gh list-pr [flags]
 
+The last fetched list is cached and shown immediately in fzf while fresh
+data is fetched in the background (requires fzf 0.36 or later).
+
 EXAMPLES
   # Launch fzf and choose a PR to checkout
   gh list-pr
@@ -47,6 +184,15 @@ EXAMPLES
   # Open selected PR in web browser
   gh list-pr -w
 
+  # List trunk and release branches of the upstream remote
+  gh list-pr --remote upstream --branch 'release/*'
+
+  # Also jump between local branches without a PR
+  gh list-pr -l
+
+  # Preview the description, checks and changed files while choosing
+  gh list-pr --preview
+
   # Switch back to the previous branch
   gh list-pr -b
 
@@ -59,6 +205,21 @@ EXAMPLES
   # Include closed/merged PRs (default: open only)
   gh list-pr -s '--state all'
 
+  # Review inbox: requests for you and your teams, your PRs, mentions
+  gh list-pr --inbox
+
+  # List PRs of several repositories in one picker
+  gh list-pr -R cli/cli -R cli/go-gh
+
+  # List PRs of every repository in an organization
+  gh list-pr --org cli
+
+  # Search PRs across GitHub, from any directory
+  gh list-pr --search 'involves:@me org:acme'
+
+  # List PRs of an organization on GitHub Enterprise Server
+  gh list-pr --hostname github.example.com --org platform
+
 FLAGS`)
 		pflag.PrintDefaults()
 	}
@@ -74,6 +235,17 @@ FLAGS`)
 		return
 	}
 
+	opt = opt.withSearchRepo()
+
+	if !slices.Contains(dateModes, opt.dateMode) {
+		fmt.Fprintf(os.Stderr, "invalid --date %q (want %s)\n", opt.dateMode, strings.Join(dateModes, ", "))
+		os.Exit(2)
+	}
+	if !slices.Contains(dateFields, opt.dateField) {
+		fmt.Fprintf(os.Stderr, "invalid --date-field %q (want %s)\n", opt.dateField, strings.Join(dateFields, ", "))
+		os.Exit(2)
+	}
+
 	if _, err := exec.LookPath("git"); err != nil {
 		fmt.Fprintln(os.Stderr, "git not found")
 		os.Exit(2)
@@ -87,64 +259,218 @@ FLAGS`)
 		return
 	}
 
-	if _, err := exec.LookPath("gh"); err != nil {
-		fmt.Fprintln(os.Stderr, "gh not found")
-		os.Exit(2)
-	}
 	if !opt.print {
+		// Listing goes through the API; gh is only needed to act on the selection.
+		if _, err := exec.LookPath("gh"); err != nil {
+			fmt.Fprintln(os.Stderr, "gh not found")
+			os.Exit(2)
+		}
 		if _, err := exec.LookPath("fzf"); err != nil {
 			fmt.Fprintln(os.Stderr, "fzf not found")
 			opt.print = true
 		}
 	}
 
-	sp := newSpinner("Fetching pull requests...")
-	sp.start()
+	if opt.hostname != "" {
+		// gh and go-gh resolve host-less repositories and pick the
+		// credentials by GH_HOST.
+		os.Setenv("GH_HOST", opt.hostname)
+	}
 
-	prs, err := fetchPRs(opt.searchOptions)
+	targets, err := searchTargets(context.Background(), opt.repos, opt.org, opt.remote, opt.hostname, opt.search != "")
 	if err != nil {
-		sp.stop()
 		fmt.Fprintf(os.Stderr, "Failed to fetch PRs: %v\n", err)
 		os.Exit(1)
 	}
+	cacheKey := opt.cacheKey(targets)
 
-	if opt.searchOptions == "" {
-		branches, err := defaultBranches()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to get default branches: %v\n", err)
-		} else {
-			prs = append(prs, branches...)
-		}
-	}
+	sp := newSpinner("Fetching pull requests...")
+	sp.start()
 
-	emoji, err := loadEmoji()
+	cached, cacheHit := loadCachedPRs(cacheKey)
+	// Swapping in fresh data uses --listen, which needs fzf 0.36.
+	cacheHit = cacheHit && !opt.print && !opt.refresh && !opt.noCache && fzfAtLeast(0, 36)
+
+	data, err := gatherList(context.Background(), opt, targets, !cacheHit)
+	sp.stop()
 	if err != nil {
-		sp.stop()
-		fmt.Fprintf(os.Stderr, "Failed to load emojis: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to %v\n", err)
 		os.Exit(1)
 	}
+	for _, w := range data.warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	prs, branches, emoji := data.prs, data.branches, data.emoji
 
-	sp.stop()
+	seen := loadSeenPRs()
+	// Every list of this run, including the fresh one replacing the cached
+	// list and those the filter keys reload, is marked unread against the
+	// state from before it, so that unread PRs stay so.
+	before := seen.clone()
 
-	for i := range prs {
-		prs[i].Title = replaceEmoji(prs[i].Title, emoji)
-		if prs[i].Author.Login != "" {
-			prs[i].AuthorName = prs[i].Author.Login
-		} else {
-			prs[i].AuthorName = "unknown"
+	// Show the cached list right away and swap in fresh data once fetched.
+	if cacheHit {
+		markUnread(cached, before)
+		shown := preparePRs(cached, branches, emoji)
+		// Choosing a PR replaces this process, possibly before the fresh
+		// list arrives, so record the cached rows now.
+		recordSeen(visiblePRs(shown, opt), seen)
+		_ = saveSeenPRs(seen)
+
+		refresh := make(chan refreshResult, 1)
+		go func() {
+			prs, err := opt.fetch(context.Background(), targets)
+			if err != nil {
+				refresh <- refreshResult{err: err}
+				return
+			}
+			_ = saveCachedPRs(cacheKey, prs)
+			markUnread(prs, before)
+			all := preparePRs(prs, branches, emoji)
+			refresh <- refreshResult{lines: renderPRs(all, opt)}
+			recordSeen(visiblePRs(all, opt), seen)
+			_ = saveSeenPRs(seen)
+		}()
+		if err := runFzf(renderPRs(shown, opt), opt, before, refresh); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
 		}
+		return
 	}
 
-	layout := calculateLayout(prs, opt)
-	lines := formatLines(prs, layout)
+	if !opt.noCache {
+		_ = saveCachedPRs(cacheKey, prs)
+	}
+
+	markUnread(prs, before)
+	all := preparePRs(prs, branches, emoji)
+	lines := renderPRs(all, opt)
+	recordSeen(visiblePRs(all, opt), seen)
+	_ = saveSeenPRs(seen)
 
 	if opt.print {
 		fmt.Print(lines)
 		return
 	}
 
-	if err := runFzf(lines, opt); err != nil {
+	if err := runFzf(lines, opt, before, nil); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
 }
+
+// listData is what a list is rendered from.
+type listData struct {
+	prs []PullRequest
+	// branches are the default and local branch rows.
+	branches []PullRequest
+	emoji    map[string]string
+	// warnings describe the branch rows that could not be listed.
+	warnings []string
+}
+
+// gatherList gets the branch rows, the emojis and, with fetch, the PRs of
+// targets. They are independent, so it gathers them concurrently.
+func gatherList(ctx context.Context, opt options, targets []searchTarget, fetch bool) (listData, error) {
+	var (
+		d         listData
+		branchErr error
+		locals    []PullRequest
+		localErr  error
+	)
+	g, ctx := errgroup.WithContext(ctx)
+	if opt.searchOptions == "" && !opt.multiRepo() && !opt.inbox {
+		g.Go(func() error {
+			var remote gitRemote
+			if remote, branchErr = baseRemote(ctx, opt.remote); branchErr == nil {
+				d.branches, branchErr = defaultBranches(ctx, remote.Name, branchPatterns(ctx, opt.branches))
+			}
+			return nil
+		})
+	}
+	if opt.local && !opt.multiRepo() && !opt.inbox {
+		g.Go(func() error {
+			locals, localErr = localBranches(ctx, opt.remote)
+			return nil
+		})
+	}
+	g.Go(func() error {
+		var err error
+		if d.emoji, err = loadEmoji(ctx, emojiHost(opt, targets)); err != nil {
+			return fmt.Errorf("load emojis: %w", err)
+		}
+		return nil
+	})
+	if fetch {
+		g.Go(func() error {
+			var err error
+			if d.prs, err = opt.fetch(ctx, targets); err != nil {
+				return fmt.Errorf("fetch PRs: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return listData{}, err
+	}
+	if branchErr != nil {
+		d.warnings = append(d.warnings, fmt.Sprintf("failed to get default branches: %v", branchErr))
+	}
+	if localErr != nil {
+		d.warnings = append(d.warnings, fmt.Sprintf("failed to get local branches: %v", localErr))
+	}
+	d.branches = append(d.branches, locals...)
+	return d, nil
+}
+
+// preparePRs appends the branch rows to prs, dropping local branches that
+// already have a row, and fills in display fields.
+func preparePRs(prs, branches []PullRequest, emoji map[string]string) []PullRequest {
+	all := make([]PullRequest, 0, len(prs)+len(branches))
+	all = append(all, prs...)
+	taken := map[string]bool{}
+	for _, pr := range prs {
+		taken[pr.HeadRefName] = true
+	}
+	for _, b := range branches {
+		if b.IsLocal && taken[b.HeadRefName] {
+			continue
+		}
+		taken[b.HeadRefName] = true
+		all = append(all, b)
+	}
+	for i := range all {
+		all[i].Title = replaceEmoji(all[i].Title, emoji)
+		if all[i].Author.Login != "" {
+			all[i].AuthorName = all[i].Author.Login
+		} else {
+			all[i].AuthorName = "unknown"
+		}
+	}
+	return all
+}
+
+func renderPRs(prs []PullRequest, opt options) string {
+	prs = stackPRs(visiblePRs(prs, opt))
+	setDates(prs, opt, time.Now())
+	layout := calculateLayout(prs, opt)
+	layout.Keys = !opt.print
+	return formatLines(prs, layout)
+}
+
+// visiblePRs applies the --base, --mergeable-only and --unread filters,
+// which keep the branch rows.
+func visiblePRs(prs []PullRequest, opt options) []PullRequest {
+	if len(opt.bases) == 0 && !opt.mergeableOnly && !opt.unread && !opt.hideDrafts {
+		return prs
+	}
+	var kept []PullRequest
+	for _, pr := range prs {
+		if pr.Number != 0 && (len(opt.bases) > 0 && !matchBranch(pr.BaseRefName, opt.bases) ||
+			opt.mergeableOnly && !isMergeable(pr) || opt.unread && !pr.Unread || opt.hideDrafts && pr.IsDraft) {
+			continue
+		}
+		kept = append(kept, pr)
+	}
+	return kept
+}
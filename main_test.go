@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryBase(t *testing.T) {
+	tests := []struct {
+		name  string
+		bases []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"literal", []string{"main"}, "main"},
+		{"literal_with_slash", []string{"release/1.0"}, "release/1.0"},
+		{"glob", []string{"release/*"}, ""},
+		{"several", []string{"main", "develop"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryBase(tt.bases); got != tt.want {
+				t.Errorf("queryBase(%q) = %q, want %q", tt.bases, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSearchRepo(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  options
+		want []string
+	}{
+		{"none", options{searchOptions: "--state all"}, nil},
+		{"short", options{searchOptions: "-R cli/cli --state all"}, []string{"cli/cli"}},
+		{"long_with_repo", options{repos: []string{"o/r"}, searchOptions: "--repo=ghes.example.com/a/b"},
+			[]string{"o/r", "ghes.example.com/a/b"}},
+		{"invalid", options{searchOptions: "-R cli/cli --bogus"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opt.withSearchRepo().repos; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withSearchRepo() repos = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisiblePRs(t *testing.T) {
+	prs := []PullRequest{
+		{Number: 1, BaseRefName: "main", MergeStateStatus: "CLEAN"},
+		{Number: 2, BaseRefName: "release/1.0", MergeStateStatus: "DIRTY", Unread: true},
+		{Number: 3, BaseRefName: "release/2.0", MergeStateStatus: "CLEAN", Unread: true, IsDraft: true},
+		{Number: 0, HeadRefName: "main"},
+	}
+	tests := []struct {
+		name string
+		opt  options
+		want []int
+	}{
+		{"no_filter", options{}, []int{1, 2, 3, 0}},
+		{"base_glob", options{bases: []string{"release/*"}}, []int{2, 3, 0}},
+		{"several_bases", options{bases: []string{"main", "release/2.0"}}, []int{1, 3, 0}},
+		{"mergeable", options{mergeableOnly: true}, []int{1, 3, 0}},
+		{"unread", options{unread: true}, []int{2, 3, 0}},
+		{"hide_drafts", options{hideDrafts: true}, []int{1, 2, 0}},
+		{"combined", options{bases: []string{"release/*"}, mergeableOnly: true}, []int{3, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, pr := range visiblePRs(prs, tt.opt) {
+				got = append(got, pr.Number)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("visiblePRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
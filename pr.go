@@ -1,13 +1,19 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io"
 	"sort"
 	"strings"
-	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/spf13/pflag"
+	"golang.org/x/sync/errgroup"
 )
 
 type Author struct {
@@ -15,99 +21,560 @@ type Author struct {
 }
 
 type PullRequest struct {
-	Number       int    `json:"number"`
-	Title        string `json:"title"`
-	HeadRefName  string `json:"headRefName"`
-	Author       Author `json:"author"`
-	CreatedAt    string `json:"createdAt"`
-	IsDraft      bool   `json:"isDraft"`
-	Additions    int    `json:"additions"`
-	Deletions    int    `json:"deletions"`
-	ChangedFiles int    `json:"changedFiles"`
-	AuthorName   string `json:"-"`
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	HeadRefName string `json:"headRefName"`
+	BaseRefName string `json:"baseRefName"`
+	// IsCrossRepository marks a PR from a fork, whose head branch is not a
+	// branch of Repository.
+	IsCrossRepository bool   `json:"isCrossRepository"`
+	Author            Author `json:"author"`
+	CreatedAt         string `json:"createdAt"`
+	IsDraft           bool   `json:"isDraft"`
+	Additions         int    `json:"additions"`
+	Deletions         int    `json:"deletions"`
+	ChangedFiles      int    `json:"changedFiles"`
+	Checks            Checks `json:"checks"`
+	Repository        string `json:"repository"` // OWNER/NAME
+
+	URL string `json:"url"`
+	// Host is the GitHub host the PR was fetched from.
+	Host string `json:"host"`
+
+	ReviewDecision string   `json:"reviewDecision"`
+	ReviewRequests []string `json:"reviewRequests"`
+	LatestReviews  []Review `json:"latestReviews"`
+
+	Labels []Label `json:"labels"`
+
+	UpdatedAt  string `json:"updatedAt"`
+	MergedAt   string `json:"mergedAt"`
+	HeadRefOid string `json:"headRefOid"`
+	// Date is the date column text; see setDates.
+	Date string `json:"-"`
+	// Unread marks a PR updated since it was last listed; see markUnread.
+	Unread bool `json:"-"`
+
+	// TreePrefix draws the PR beneath the PR it is stacked on; see stackPRs.
+	TreePrefix string `json:"-"`
+
+	// Section is the --inbox group the PR is listed under.
+	Section string `json:"section,omitempty"`
+
+	Comments          int `json:"comments"`
+	ReviewThreads     int `json:"reviewThreads"`
+	UnresolvedThreads int `json:"unresolvedThreads"`
+
+	Mergeable        string `json:"mergeable"`        // MERGEABLE, CONFLICTING or UNKNOWN
+	MergeStateStatus string `json:"mergeStateStatus"` // CLEAN, DIRTY, BEHIND, BLOCKED, UNSTABLE, ...
+
+	// IsLocal marks a local branch row (Number 0) as opposed to a default
+	// branch row.
+	IsLocal bool `json:"isLocal"`
+
+	AuthorName string `json:"-"`
+}
+
+// Review is the latest review state left by one reviewer.
+type Review struct {
+	Author string `json:"author"`
+	State  string `json:"state"`
+}
+
+// Checks counts the CI contexts of a PR's head commit by outcome, following
+// the same buckets as gh pr checks.
+type Checks struct {
+	Pass    int `json:"pass"`
+	Fail    int `json:"fail"`
+	Pending int `json:"pending"`
 }
 
-func fetchPRs(searchOptions string) ([]PullRequest, error) {
-	args := []string{"pr", "list"}
-	if searchOptions != "" {
-		args = append(args, strings.Fields(searchOptions)...)
+func (c Checks) Total() int {
+	return c.Pass + c.Fail + c.Pending
+}
+
+func (c *Checks) add(state string, count int) {
+	switch state {
+	case "SUCCESS", "NEUTRAL", "SKIPPED":
+		c.Pass += count
+	case "FAILURE", "ERROR", "CANCELLED", "TIMED_OUT", "ACTION_REQUIRED", "STARTUP_FAILURE":
+		c.Fail += count
+	default:
+		c.Pending += count
 	}
-	args = append(args, "--json", "number,title,headRefName,author,createdAt,isDraft,additions,deletions,changedFiles")
+}
+
+type stateCount struct {
+	State string `json:"state"`
+	Count int    `json:"count"`
+}
+
+// prNode is a pull request as returned by GraphQL, before nested connections
+// are flattened into PullRequest.
+type prNode struct {
+	PullRequest
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+	Commits struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					Contexts struct {
+						CheckRunCountsByState      []stateCount `json:"checkRunCountsByState"`
+						StatusContextCountsByState []stateCount `json:"statusContextCountsByState"`
+					} `json:"contexts"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer struct {
+				Login string `json:"login"`
+				Slug  string `json:"slug"`
+			} `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+	LatestReviews struct {
+		Nodes []struct {
+			Author Author `json:"author"`
+			State  string `json:"state"`
+		} `json:"nodes"`
+	} `json:"latestReviews"`
+	Labels struct {
+		Nodes []Label `json:"nodes"`
+	} `json:"labels"`
+	Comments struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+	ReviewThreads struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			IsResolved bool `json:"isResolved"`
+		} `json:"nodes"`
+	} `json:"reviewThreads"`
+}
 
-	cmd := exec.Command("gh", args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("gh pr list: %w: %s", err, stderr.String())
+// repoKey identifies the repository of pr across hosts as HOST/OWNER/NAME,
+// which gh's -R accepts. Branch rows have no repository.
+func (pr PullRequest) repoKey() string {
+	if pr.Repository == "" {
+		return ""
 	}
+	return pr.Host + "/" + pr.Repository
+}
 
-	var prs []PullRequest
-	if err := json.Unmarshal(stdout.Bytes(), &prs); err != nil {
-		return nil, fmt.Errorf("decode JSON: %w", err)
+func (n prNode) toPullRequest() PullRequest {
+	pr := n.PullRequest
+	pr.Repository = n.Repository.NameWithOwner
+	for _, c := range n.Commits.Nodes {
+		rollup := c.Commit.StatusCheckRollup
+		if rollup == nil {
+			continue
+		}
+		for _, sc := range rollup.Contexts.CheckRunCountsByState {
+			pr.Checks.add(sc.State, sc.Count)
+		}
+		for _, sc := range rollup.Contexts.StatusContextCountsByState {
+			pr.Checks.add(sc.State, sc.Count)
+		}
 	}
-	return prs, nil
+	for _, r := range n.ReviewRequests.Nodes {
+		if r.RequestedReviewer.Login != "" {
+			pr.ReviewRequests = append(pr.ReviewRequests, r.RequestedReviewer.Login)
+		} else if r.RequestedReviewer.Slug != "" {
+			pr.ReviewRequests = append(pr.ReviewRequests, r.RequestedReviewer.Slug)
+		}
+	}
+	for _, r := range n.LatestReviews.Nodes {
+		pr.LatestReviews = append(pr.LatestReviews, Review{Author: r.Author.Login, State: r.State})
+	}
+	pr.Labels = n.Labels.Nodes
+	pr.Comments = n.Comments.TotalCount
+	pr.ReviewThreads = n.ReviewThreads.TotalCount
+	for _, t := range n.ReviewThreads.Nodes {
+		if !t.IsResolved {
+			pr.UnresolvedThreads++
+		}
+	}
+	return pr
 }
 
-func defaultBranches() ([]PullRequest, error) {
-	cmd := exec.Command("git", "branch", "-r")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("git branch -r: %w", err)
+// searchQuery lists pull requests through the search API, which is what
+// gh pr list uses as well once any filter is given.
+const searchQuery = `query PullRequestList($q: String!, $first: Int!, $after: String) {
+	search(query: $q, type: ISSUE, first: $first, after: $after) {
+		pageInfo { hasNextPage endCursor }
+		nodes { ... on PullRequest { ` + prFields + ` } }
 	}
+}`
 
-	wanted := map[string]bool{
-		"main": true, "master": true, "develop": true, "staging": true,
+const prFields = `
+	number
+	url
+	repository { nameWithOwner }
+	title
+	headRefName
+	baseRefName
+	isCrossRepository
+	author { login }
+	createdAt
+	updatedAt
+	mergedAt
+	headRefOid
+	isDraft
+	additions
+	deletions
+	changedFiles
+	commits(last: 1) {
+		nodes { commit { statusCheckRollup { contexts(first: 1) {
+			checkRunCountsByState { state count }
+			statusContextCountsByState { state count }
+		} } } }
+	}
+	reviewDecision
+	reviewRequests(first: 10) {
+		nodes { requestedReviewer {
+			... on User { login }
+			... on Team { slug }
+		} }
 	}
+	latestReviews(first: 10) { nodes { author { login } state } }
+	labels(first: 10) { nodes { name color } }
+	mergeable
+	mergeStateStatus
+	comments { totalCount }
+	reviewThreads(first: 100) { totalCount nodes { isResolved } }
+`
 
-	var branches []string
-	for _, line := range strings.Split(stdout.String(), "\n") {
-		line = strings.TrimSpace(line)
-		if !strings.HasPrefix(line, "origin/") {
-			continue
+var (
+	errUnauthorized = errors.New("not authenticated (run `gh auth login`)")
+	errRateLimited  = errors.New("API rate limit exceeded")
+	errNotFound     = errors.New("repository not found")
+)
+
+// listFilter holds the gh pr list flags accepted by --search-options.
+type listFilter struct {
+	state    string
+	limit    int
+	author   string
+	assignee string
+	labels   []string
+	base     string
+	head     string
+	draft    bool
+	app      string
+	search   string
+	// repo is gh pr list's -R. It is listed like --repo rather than
+	// searched; see options.withSearchRepo.
+	repo string
+}
+
+func parseSearchOptions(args []string) (listFilter, error) {
+	var f listFilter
+	fs := pflag.NewFlagSet("search-options", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVarP(&f.state, "state", "s", "open", "")
+	fs.IntVarP(&f.limit, "limit", "L", 30, "")
+	fs.StringVarP(&f.author, "author", "A", "", "")
+	fs.StringVarP(&f.assignee, "assignee", "a", "", "")
+	fs.StringSliceVarP(&f.labels, "label", "l", nil, "")
+	fs.StringVarP(&f.base, "base", "B", "", "")
+	fs.StringVarP(&f.head, "head", "H", "", "")
+	fs.BoolVarP(&f.draft, "draft", "d", false, "")
+	fs.StringVar(&f.app, "app", "", "")
+	fs.StringVarP(&f.search, "search", "S", "", "")
+	fs.StringVarP(&f.repo, "repo", "R", "", "")
+	if err := fs.Parse(args); err != nil {
+		return f, fmt.Errorf("search options: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return f, fmt.Errorf("search options: unexpected argument %q", fs.Arg(0))
+	}
+	switch f.state {
+	case "open", "closed", "merged", "all":
+	default:
+		return f, fmt.Errorf("search options: invalid state %q (want open, closed, merged or all)", f.state)
+	}
+	if f.limit <= 0 {
+		return f, fmt.Errorf("search options: invalid limit %d", f.limit)
+	}
+	return f, nil
+}
+
+// query translates the filter into a GitHub search query within scope, or
+// across GitHub when scope is empty.
+func (f listFilter) query(scope string) string {
+	var terms []string
+	if scope != "" {
+		terms = append(terms, scope)
+	}
+	terms = append(terms, "is:pr")
+	if f.state != "all" && !hasStateQualifier(f.search) {
+		terms = append(terms, "is:"+f.state)
+	}
+	if f.author != "" {
+		terms = append(terms, "author:"+f.author)
+	}
+	if f.app != "" {
+		terms = append(terms, "author:app/"+f.app)
+	}
+	if f.assignee != "" {
+		terms = append(terms, "assignee:"+f.assignee)
+	}
+	for _, l := range f.labels {
+		terms = append(terms, "label:"+quoteSearchValue(l))
+	}
+	if f.base != "" {
+		terms = append(terms, "base:"+quoteSearchValue(f.base))
+	}
+	if f.head != "" {
+		terms = append(terms, "head:"+quoteSearchValue(f.head))
+	}
+	if f.draft {
+		terms = append(terms, "draft:true")
+	}
+	if f.search != "" {
+		terms = append(terms, f.search)
+	}
+	if !strings.Contains(f.search, "sort:") {
+		terms = append(terms, "sort:created-desc")
+	}
+	return strings.Join(terms, " ")
+}
+
+// hasStateQualifier reports whether the search text already restricts the
+// state, in which case --state is not added on top of it.
+func hasStateQualifier(search string) bool {
+	for _, term := range strings.Fields(search) {
+		switch strings.TrimPrefix(term, "-") {
+		case "is:open", "is:closed", "is:merged", "is:unmerged", "state:open", "state:closed":
+			return true
 		}
-		name := strings.TrimPrefix(line, "origin/")
-		if wanted[name] {
-			branches = append(branches, name)
+	}
+	return false
+}
+
+func quoteSearchValue(v string) string {
+	if strings.ContainsAny(v, " \t\"") {
+		return `"` + strings.ReplaceAll(v, `"`, "") + `"`
+	}
+	return v
+}
+
+// searchTarget is one search scope, e.g. "repo:cli/cli" or "org:cli", on a
+// host. An empty scope searches the whole host.
+type searchTarget struct {
+	host  string
+	scope string
+}
+
+// searchTargets resolves the repositories to list. hostname, if set, is the
+// host of --org and of --repo values without one; otherwise gh's default
+// host is used. With global and no repositories, the search spans all of
+// the host instead of the current repository.
+func searchTargets(ctx context.Context, repos []string, org, remote, hostname string, global bool) ([]searchTarget, error) {
+	if hostname == "" {
+		hostname, _ = auth.DefaultHost()
+	}
+
+	if len(repos) == 0 && org == "" && global {
+		return []searchTarget{{host: hostname}}, nil
+	}
+	if len(repos) == 0 && org == "" {
+		repo, err := currentRepo(ctx, remote)
+		if err != nil {
+			return nil, fmt.Errorf("resolve repository: %w", err)
 		}
+		return []searchTarget{{host: repo.Host, scope: "repo:" + repo.Owner + "/" + repo.Name}}, nil
 	}
-	sort.Strings(branches)
 
-	var prs []PullRequest
-	for _, branch := range branches {
-		epoch, err := branchFirstCommitTime(branch)
+	var targets []searchTarget
+	for _, r := range repos {
+		repo, err := repository.ParseWithHost(r, hostname)
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("--repo %s: %w", r, err)
+		}
+		targets = append(targets, searchTarget{host: repo.Host, scope: "repo:" + repo.Owner + "/" + repo.Name})
+	}
+	if org != "" {
+		targets = append(targets, searchTarget{host: hostname, scope: "org:" + org})
+	}
+	return targets, nil
+}
+
+// searchFilter parses the --search-options string. A non-empty base
+// narrows the query to that base branch.
+func searchFilter(searchOptions, base string) (listFilter, error) {
+	args, err := splitShellWords(searchOptions)
+	if err != nil {
+		return listFilter{}, fmt.Errorf("search options: %w", err)
+	}
+	filter, err := parseSearchOptions(args)
+	if err != nil {
+		return filter, err
+	}
+	if base != "" {
+		filter.base = base
+	}
+	return filter, nil
+}
+
+// fetchPRs lists PRs in each of targets, fetching them concurrently.
+func fetchPRs(ctx context.Context, filter listFilter, targets []searchTarget) ([]PullRequest, error) {
+	groups, err := searchGroups(ctx, targets, []listFilter{filter})
+	if err != nil {
+		return nil, err
+	}
+	return groups[0], nil
+}
+
+// inboxSections are the groups listed by --inbox, in display order. A PR
+// is listed only under the first section it matches, which separates
+// direct review requests from those via a team.
+var inboxSections = []struct {
+	name      string
+	qualifier string
+}{
+	{"Review requested from you", "user-review-requested:@me"},
+	{"Review requested from your teams", "review-requested:@me"},
+	{"Your pull requests", "author:@me"},
+	{"Mentioning you", "mentions:@me"},
+}
+
+// fetchInbox lists the PRs of each inbox section in targets, narrowed by
+// filter, with their Section set.
+func fetchInbox(ctx context.Context, filter listFilter, targets []searchTarget) ([]PullRequest, error) {
+	filters := make([]listFilter, len(inboxSections))
+	for i, s := range inboxSections {
+		filters[i] = filter
+		filters[i].search = strings.TrimSpace(s.qualifier + " " + filter.search)
+	}
+	groups, err := searchGroups(ctx, targets, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PullRequest
+	seen := map[string]bool{}
+	for i, group := range groups {
+		for _, pr := range group {
+			key := fmt.Sprintf("%s#%d", pr.repoKey(), pr.Number)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			pr.Section = inboxSections[i].name
+			prs = append(prs, pr)
 		}
-		prs = append(prs, PullRequest{
-			Number:      0,
-			Title:       branch,
-			HeadRefName: branch,
-			Author:      Author{Login: "system"},
-			CreatedAt:   epoch.UTC().Format("2006-01-02T15:04:05Z"),
-			IsDraft:     false,
-			Additions:   0,
-			Deletions:   0,
-		})
 	}
 	return prs, nil
 }
 
-func branchFirstCommitTime(branch string) (time.Time, error) {
-	cmd := exec.Command("git", "log", "origin/"+branch, "--reverse", "--pretty=format:%ct")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-	if err := cmd.Run(); err != nil {
-		return time.Time{}, err
+// searchGroups runs each filter against every target concurrently and
+// returns the PRs per filter, newest first when merged from several targets.
+func searchGroups(ctx context.Context, targets []searchTarget, filters []listFilter) ([][]PullRequest, error) {
+	results := make([][][]PullRequest, len(filters))
+	g, ctx := errgroup.WithContext(ctx)
+	for i, filter := range filters {
+		results[i] = make([][]PullRequest, len(targets))
+		for j, t := range targets {
+			g.Go(func() error {
+				var err error
+				results[i][j], err = searchPRs(ctx, t, filter)
+				return err
+			})
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	groups := make([][]PullRequest, len(filters))
+	for i, perTarget := range results {
+		for _, r := range perTarget {
+			groups[i] = append(groups[i], r...)
+		}
+		if len(targets) > 1 {
+			sort.SliceStable(groups[i], func(a, b int) bool {
+				return groups[i][a].CreatedAt > groups[i][b].CreatedAt
+			})
+		}
+	}
+	return groups, nil
+}
+
+func searchPRs(ctx context.Context, t searchTarget, filter listFilter) ([]PullRequest, error) {
+	client, err := api.NewGraphQLClient(api.ClientOptions{Host: t.host})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnauthorized, err)
 	}
-	lines := strings.SplitN(stdout.String(), "\n", 2)
-	if len(lines) == 0 || lines[0] == "" {
-		return time.Time{}, fmt.Errorf("no commits for %s", branch)
+
+	q := filter.query(t.scope)
+	var prs []PullRequest
+	var after interface{}
+	for len(prs) < filter.limit {
+		var resp struct {
+			Search struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []prNode `json:"nodes"`
+			} `json:"search"`
+		}
+		vars := map[string]interface{}{
+			"q":     q,
+			"first": min(100, filter.limit-len(prs)),
+			"after": after,
+		}
+		if err := client.DoWithContext(ctx, searchQuery, vars, &resp); err != nil {
+			return nil, fmt.Errorf("%s: %w", cmp.Or(t.scope, t.host), classifyAPIError(err))
+		}
+		for _, n := range resp.Search.Nodes {
+			if n.Number != 0 {
+				pr := n.toPullRequest()
+				pr.Host = t.host
+				prs = append(prs, pr)
+			}
+		}
+		if !resp.Search.PageInfo.HasNextPage {
+			break
+		}
+		after = resp.Search.PageInfo.EndCursor
 	}
-	var epoch int64
-	if _, err := fmt.Sscanf(lines[0], "%d", &epoch); err != nil {
-		return time.Time{}, err
+	return prs, nil
+}
+
+// classifyAPIError wraps err with one of the sentinel errors above so that
+// callers can tell auth, rate-limit and not-found failures apart.
+func classifyAPIError(err error) error {
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) {
+		switch {
+		case httpErr.StatusCode == 401:
+			return fmt.Errorf("%w: %v", errUnauthorized, err)
+		case httpErr.StatusCode == 429,
+			httpErr.StatusCode == 403 && httpErr.Headers.Get("X-RateLimit-Remaining") == "0":
+			return fmt.Errorf("%w: %v", errRateLimited, err)
+		case httpErr.StatusCode == 404:
+			return fmt.Errorf("%w: %v", errNotFound, err)
+		}
+		return err
+	}
+
+	var gqlErr *api.GraphQLError
+	if errors.As(err, &gqlErr) {
+		for _, e := range gqlErr.Errors {
+			switch {
+			case e.Type == "RATE_LIMITED":
+				return fmt.Errorf("%w: %v", errRateLimited, err)
+			case e.Type == "NOT_FOUND",
+				strings.Contains(e.Message, "cannot be searched"):
+				return fmt.Errorf("%w: %v", errNotFound, err)
+			}
+		}
 	}
-	return time.Unix(epoch, 0), nil
+	return err
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestParseSearchOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"defaults", nil, "repo:o/r is:pr is:open sort:created-desc", false},
+		{"state_all", []string{"--state", "all"}, "repo:o/r is:pr sort:created-desc", false},
+		{"state_merged_short", []string{"-s", "merged"}, "repo:o/r is:pr is:merged sort:created-desc", false},
+		{"author", []string{"--author=@me"}, "repo:o/r is:pr is:open author:@me sort:created-desc", false},
+		{"labels", []string{"-l", "bug", "--label", "needs qa"},
+			`repo:o/r is:pr is:open label:bug label:"needs qa" sort:created-desc`, false},
+		{"base_head_draft", []string{"-B", "main", "-H", "feat", "-d"},
+			"repo:o/r is:pr is:open base:main head:feat draft:true sort:created-desc", false},
+		{"app", []string{"--app", "dependabot"}, "repo:o/r is:pr is:open author:app/dependabot sort:created-desc", false},
+		{"search", []string{"-S", "fix"}, "repo:o/r is:pr is:open fix sort:created-desc", false},
+		{"search_with_sort", []string{"-S", "sort:updated-desc"}, "repo:o/r is:pr is:open sort:updated-desc", false},
+		{"search_with_state", []string{"-S", "is:merged review:approved"},
+			"repo:o/r is:pr is:merged review:approved sort:created-desc", false},
+		{"repo_not_searched", []string{"-R", "cli/cli"}, "repo:o/r is:pr is:open sort:created-desc", false},
+		{"invalid_state", []string{"--state", "bogus"}, "", true},
+		{"invalid_limit", []string{"--limit", "0"}, "", true},
+		{"unknown_flag", []string{"--bogus"}, "", true},
+		{"positional", []string{"extra"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseSearchOptions(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSearchOptions(%q) error = nil, want error", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSearchOptions(%q) error = %v", tt.args, err)
+			}
+			if got := f.query("repo:o/r"); got != tt.want {
+				t.Errorf("query() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		f, err := parseSearchOptions([]string{"-L", "100"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.limit != 100 {
+			t.Errorf("limit = %d, want 100", f.limit)
+		}
+	})
+}
+
+func TestGlobalQuery(t *testing.T) {
+	f, err := parseSearchOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.search = "involves:@me org:acme"
+	if got, want := f.query(""), "is:pr is:open involves:@me org:acme sort:created-desc"; got != want {
+		t.Errorf("query() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	f, err := searchFilter("--base develop -S 'fix bug'", "release/1.0")
+	if err != nil {
+		t.Fatalf("searchFilter() error = %v", err)
+	}
+	if got, want := f.query("repo:o/r"), "repo:o/r is:pr is:open base:release/1.0 fix bug sort:created-desc"; got != want {
+		t.Errorf("query() = %q, want %q", got, want)
+	}
+	if _, err := searchFilter("-S 'unterminated", ""); err == nil {
+		t.Error("searchFilter() with an unterminated quote should fail")
+	}
+}
+
+func TestClassifyAPIError(t *testing.T) {
+	rateHeaders := http.Header{}
+	rateHeaders.Set("X-RateLimit-Remaining", "0")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unauthorized", &api.HTTPError{StatusCode: 401}, errUnauthorized},
+		{"rate_limit_403", &api.HTTPError{StatusCode: 403, Headers: rateHeaders}, errRateLimited},
+		{"rate_limit_429", &api.HTTPError{StatusCode: 429}, errRateLimited},
+		{"forbidden", &api.HTTPError{StatusCode: 403, Headers: http.Header{}}, nil},
+		{"http_not_found", &api.HTTPError{StatusCode: 404}, errNotFound},
+		{"graphql_rate_limited", &api.GraphQLError{Errors: []api.GraphQLErrorItem{{Type: "RATE_LIMITED"}}}, errRateLimited},
+		{"graphql_not_found", &api.GraphQLError{Errors: []api.GraphQLErrorItem{{Type: "NOT_FOUND"}}}, errNotFound},
+		{"graphql_cannot_search", &api.GraphQLError{Errors: []api.GraphQLErrorItem{
+			{Type: "INVALID", Message: "The listed users and repositories cannot be searched"},
+		}}, errNotFound},
+		{"other", errors.New("boom"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyAPIError(tt.err)
+			for _, sentinel := range []error{errUnauthorized, errRateLimited, errNotFound} {
+				if is := errors.Is(got, sentinel); is != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", got, sentinel, is)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchTargetsHostname(t *testing.T) {
+	t.Setenv("GH_HOST", "")
+	targets, err := searchTargets(context.Background(),
+		[]string{"cli/cli", "github.com/cli/go-gh"}, "platform", "", "github.example.com", true)
+	if err != nil {
+		t.Fatalf("searchTargets() error = %v", err)
+	}
+	want := []searchTarget{
+		{host: "github.example.com", scope: "repo:cli/cli"},
+		{host: "github.com", scope: "repo:cli/go-gh"},
+		{host: "github.example.com", scope: "org:platform"},
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Errorf("searchTargets() = %+v, want %+v", targets, want)
+	}
+
+	// A global search does not need a repository.
+	t.Chdir(t.TempDir())
+	targets, err = searchTargets(context.Background(), nil, "", "", "github.example.com", true)
+	if err != nil {
+		t.Fatalf("searchTargets() global error = %v", err)
+	}
+	if want := []searchTarget{{host: "github.example.com"}}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("searchTargets() global = %+v, want %+v", targets, want)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/markdown"
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/spf13/pflag"
+)
+
+// previewPR is a PR with what the fzf preview shows beyond the list row.
+type previewPR struct {
+	PullRequest
+	State string // OPEN, CLOSED or MERGED
+	Body  string
+	Runs  []checkResult
+	Files []fileStat
+}
+
+// checkResult is a check run or commit status and its outcome.
+type checkResult struct {
+	Name  string
+	State string // as bucketed by Checks.add
+}
+
+type fileStat struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+const previewQuery = `query($owner: String!, $name: String!, $number: Int!) {
+	repository(owner: $owner, name: $name) {
+		pullRequest(number: $number) {
+			state
+			body
+			` + prFields + `
+			checkContexts: commits(last: 1) { nodes { commit { statusCheckRollup { contexts(first: 100) { nodes {
+				__typename
+				... on CheckRun { name status conclusion }
+				... on StatusContext { context state }
+			} } } } } }
+			files(first: 100) { nodes { path additions deletions } }
+		}
+	}
+}`
+
+// runPreview implements the hidden __preview subcommand run by fzf's
+// --preview. Its argument is the repository and number key fields of a row
+// joined by '#', "HOST/OWNER/REPO#42", or "#0" for a branch.
+func runPreview(args []string) error {
+	var remote string
+	fs := pflag.NewFlagSet("__preview", pflag.ContinueOnError)
+	fs.StringVar(&remote, "remote", "", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: gh list-pr __preview [--remote REMOTE] [[HOST/]OWNER/REPO]#NUMBER")
+	}
+
+	name, number, _ := strings.Cut(fs.Arg(0), "#")
+	if name == "" && number == "" {
+		return nil // an --inbox section header
+	}
+	num, err := strconv.Atoi(number)
+	if err != nil {
+		return fmt.Errorf("invalid PR reference %q", fs.Arg(0))
+	}
+	if num == 0 {
+		fmt.Println("No pull request for this branch.")
+		return nil
+	}
+
+	ctx := context.Background()
+	var repo repository.Repository
+	if name == "" {
+		repo, err = currentRepo(ctx, remote)
+	} else {
+		repo, err = repository.Parse(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	pr, err := fetchPreview(ctx, repo, num)
+	if err != nil {
+		return err
+	}
+	width, _ := strconv.Atoi(os.Getenv("FZF_PREVIEW_COLUMNS"))
+	return renderPreview(os.Stdout, pr, width)
+}
+
+func fetchPreview(ctx context.Context, repo repository.Repository, number int) (previewPR, error) {
+	client, err := api.NewGraphQLClient(api.ClientOptions{Host: repo.Host})
+	if err != nil {
+		return previewPR{}, fmt.Errorf("%w: %v", errUnauthorized, err)
+	}
+
+	var resp struct {
+		Repository struct {
+			PullRequest *struct {
+				prNode
+				State         string `json:"state"`
+				Body          string `json:"body"`
+				CheckContexts struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								Contexts struct {
+									Nodes []struct {
+										Typename   string `json:"__typename"`
+										Name       string `json:"name"`
+										Status     string `json:"status"`
+										Conclusion string `json:"conclusion"`
+										Context    string `json:"context"`
+										State      string `json:"state"`
+									} `json:"nodes"`
+								} `json:"contexts"`
+							} `json:"statusCheckRollup"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"checkContexts"`
+				Files struct {
+					Nodes []fileStat `json:"nodes"`
+				} `json:"files"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": repo.Owner, "name": repo.Name, "number": number}
+	if err := client.DoWithContext(ctx, previewQuery, vars, &resp); err != nil {
+		return previewPR{}, classifyAPIError(err)
+	}
+	node := resp.Repository.PullRequest
+	if node == nil {
+		return previewPR{}, fmt.Errorf("%s/%s#%d: %w", repo.Owner, repo.Name, number, errNotFound)
+	}
+
+	pr := previewPR{PullRequest: node.toPullRequest(), State: node.State, Body: node.Body, Files: node.Files.Nodes}
+	pr.AuthorName = pr.Author.Login
+	for _, c := range node.CheckContexts.Nodes {
+		if c.Commit.StatusCheckRollup == nil {
+			continue
+		}
+		for _, n := range c.Commit.StatusCheckRollup.Contexts.Nodes {
+			var check Checks
+			if n.Typename == "CheckRun" {
+				state := n.Conclusion
+				if state == "" {
+					state = n.Status
+				}
+				check.add(state, 1)
+				pr.Runs = append(pr.Runs, checkResult{Name: n.Name, State: checkBucket(check)})
+			} else {
+				check.add(n.State, 1)
+				pr.Runs = append(pr.Runs, checkResult{Name: n.Context, State: checkBucket(check)})
+			}
+		}
+	}
+	return pr, nil
+}
+
+// checkBucket names the bucket a single check was counted in.
+func checkBucket(c Checks) string {
+	switch {
+	case c.Fail > 0:
+		return "fail"
+	case c.Pending > 0:
+		return "pending"
+	}
+	return "pass"
+}
+
+// renderPreview writes the header, review and check summaries, the
+// description rendered from markdown and the per-file diffstat of pr.
+func renderPreview(w io.Writer, pr previewPR, width int) error {
+	fmt.Fprintf(w, "%s#%d%s %s%s%s\n", green, pr.Number, reset, bold, pr.Title, reset)
+	fmt.Fprintf(w, "%s%s%s wants to merge into %s%s%s from %s%s%s (%s)\n",
+		magenta, pr.AuthorName, reset, blue, pr.BaseRefName, reset, cyan, pr.HeadRefName, reset, strings.ToLower(pr.State))
+	fmt.Fprintf(w, "%s%s%s\n\n", brightBlack, pr.URL, reset)
+
+	if text := reviewText(pr.PullRequest); text != "" {
+		fmt.Fprintf(w, "Review:  %s\n", formatReview(pr.PullRequest, displayWidth(text)))
+	}
+	if text := mergeText(pr.PullRequest); text != "" {
+		fmt.Fprintf(w, "Merge:   %s %s\n", formatMerge(pr.PullRequest), strings.ToLower(pr.MergeStateStatus))
+	}
+	if pr.Checks.Total() > 0 {
+		fmt.Fprintf(w, "Checks:  %s\n", formatChecks(pr.Checks, displayWidth(checksText(pr.Checks))))
+		for _, r := range pr.Runs {
+			switch r.State {
+			case "fail":
+				fmt.Fprintf(w, "  %sX %s%s\n", red, r.Name, reset)
+			case "pending":
+				fmt.Fprintf(w, "  %s* %s%s\n", yellow, r.Name, reset)
+			}
+		}
+	}
+
+	body := strings.TrimSpace(pr.Body)
+	if body == "" {
+		body = "_No description provided._"
+	}
+	// GLAMOUR_STYLE overrides the theme, as in gh.
+	opts := []glamour.TermRendererOption{
+		markdown.WithTheme("dark"),
+		markdown.WithoutIndentation(),
+		markdown.WithBaseURL(pr.URL),
+	}
+	if width > 0 {
+		opts = append(opts, markdown.WithWrap(width))
+	}
+	rendered, err := markdown.Render(body, opts...)
+	if err != nil {
+		rendered = body + "\n"
+	}
+	fmt.Fprint(w, rendered)
+
+	if len(pr.Files) > 0 {
+		addW, delW := 1, 1
+		for _, f := range pr.Files {
+			addW = max(addW, len(strconv.Itoa(f.Additions)))
+			delW = max(delW, len(strconv.Itoa(f.Deletions)))
+		}
+		fmt.Fprintf(w, "\n%d files changed\n", pr.ChangedFiles)
+		for _, f := range pr.Files {
+			fmt.Fprintf(w, "%s+%*d%s %s-%*d%s  %s\n",
+				green, addW, f.Additions, reset, red, delW, f.Deletions, reset, f.Path)
+		}
+		if pr.ChangedFiles > len(pr.Files) {
+			fmt.Fprintf(w, "%s... and %d more%s\n", brightBlack, pr.ChangedFiles-len(pr.Files), reset)
+		}
+	}
+	return nil
+}
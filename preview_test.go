@@ -0,0 +1,85 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRenderPreview(t *testing.T) {
+	pr := previewPR{
+		PullRequest: PullRequest{
+			Number: 42, Title: "Fix the widget", AuthorName: "alice",
+			BaseRefName: "main", HeadRefName: "fix-widget",
+			ChangedFiles: 3,
+			Checks:       Checks{Pass: 1, Fail: 1, Pending: 1},
+			URL:          "https://github.com/o/r/pull/42",
+		},
+		State: "OPEN",
+		Body:  "Fixes **everything**.",
+		Runs: []checkResult{
+			{Name: "lint", State: "pass"},
+			{Name: "test", State: "fail"},
+			{Name: "deploy", State: "pending"},
+		},
+		Files: []fileStat{
+			{Path: "widget.go", Additions: 120, Deletions: 4},
+			{Path: "widget_test.go", Additions: 8, Deletions: 0},
+		},
+	}
+	var b strings.Builder
+	if err := renderPreview(&b, pr, 60); err != nil {
+		t.Fatal(err)
+	}
+	got := regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(b.String(), "")
+
+	for _, want := range []string{
+		"#42 Fix the widget\n",
+		"alice wants to merge into main from fix-widget (open)\n",
+		"  X test\n",
+		"  * deploy\n",
+		"everything",
+		"3 files changed\n",
+		"+120 -4  widget.go\n",
+		"+  8 -0  widget_test.go\n",
+		"... and 1 more\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderPreview() missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "lint") {
+		t.Errorf("renderPreview() lists a passing check:\n%s", got)
+	}
+}
+
+func TestCheckBucket(t *testing.T) {
+	tests := []struct {
+		state string
+		want  string
+	}{
+		{"SUCCESS", "pass"},
+		{"FAILURE", "fail"},
+		{"IN_PROGRESS", "pending"},
+		{"SKIPPED", "pass"},
+	}
+	for _, tt := range tests {
+		var c Checks
+		c.add(tt.state, 1)
+		if got := checkBucket(c); got != tt.want {
+			t.Errorf("checkBucket(%s) = %q, want %q", tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestRunPreviewNoPR(t *testing.T) {
+	if err := runPreview([]string{"#0"}); err != nil {
+		t.Errorf("runPreview(#0) error = %v", err)
+	}
+	if err := runPreview([]string{"#"}); err != nil {
+		t.Errorf("runPreview(#) error = %v", err)
+	}
+	if err := runPreview([]string{"#abc"}); err == nil {
+		t.Error("runPreview(#abc) should fail")
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+// gitRemote is a git remote pointing at a GitHub repository.
+type gitRemote struct {
+	Name string
+	Repo repository.Repository
+	// Resolved is remote.<name>.gh-resolved as set by gh repo set-default:
+	// "base" or the OWNER/REPO of the base repository.
+	Resolved string
+}
+
+// remoteScore orders remotes the way gh does when choosing a base repository.
+func remoteScore(name string) int {
+	switch strings.ToLower(name) {
+	case "upstream":
+		return 3
+	case "github":
+		return 2
+	case "origin":
+		return 1
+	}
+	return 0
+}
+
+// gitRemotes lists the remotes whose fetch URL is a repository URL, most
+// likely base repository first.
+func gitRemotes(ctx context.Context) ([]gitRemote, error) {
+	out, err := gitOutput(ctx, "remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+	var remotes []gitRemote
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[2] != "(fetch)" {
+			continue
+		}
+		repo, err := repository.Parse(fields[1])
+		if err != nil {
+			continue
+		}
+		remotes = append(remotes, gitRemote{Name: fields[0], Repo: repo})
+	}
+
+	if out, err := gitOutput(ctx, "config", "--get-regexp", `^remote\..*\.gh-resolved$`); err == nil {
+		for _, line := range strings.Split(out, "\n") {
+			key, value, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".gh-resolved")
+			for i := range remotes {
+				if remotes[i].Name == name {
+					remotes[i].Resolved = value
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(remotes, func(i, j int) bool {
+		return remoteScore(remotes[i].Name) > remoteScore(remotes[j].Name)
+	})
+	return remotes, nil
+}
+
+// baseRemote finds the remote of the repository PRs are opened against.
+// Like gh, it honors gh repo set-default and otherwise prefers upstream,
+// github and origin in that order. When the preferred remote is a fork of
+// another configured remote, the parent wins, so that working in a fork
+// cloned as origin with the original added under another name still pulls
+// default branches from the original. The --remote flag and the
+// gh-list-pr.remote git config override all of this.
+func baseRemote(ctx context.Context, override string) (gitRemote, error) {
+	remotes, err := gitRemotes(ctx)
+	if err != nil {
+		return gitRemote{}, err
+	}
+	if len(remotes) == 0 {
+		return gitRemote{}, fmt.Errorf("no git remotes configured for a GitHub repository")
+	}
+
+	if override == "" {
+		if out, err := gitOutput(ctx, "config", "--get", "gh-list-pr.remote"); err == nil {
+			override = out
+		}
+	}
+	if override != "" {
+		for _, r := range remotes {
+			if r.Name == override {
+				return r, nil
+			}
+		}
+		return gitRemote{}, fmt.Errorf("remote %q not found", override)
+	}
+
+	for _, r := range remotes {
+		switch r.Resolved {
+		case "":
+		case "base":
+			return r, nil
+		default:
+			if found, ok := findRemote(remotes, r.Resolved); ok {
+				return found, nil
+			}
+			return r, nil
+		}
+	}
+
+	first := remotes[0]
+	if len(remotes) > 1 && !strings.EqualFold(first.Name, "upstream") {
+		if parent := forkParent(ctx, first.Repo); parent != "" {
+			if found, ok := findRemote(remotes, parent); ok {
+				return found, nil
+			}
+		}
+	}
+	return first, nil
+}
+
+func findRemote(remotes []gitRemote, nameWithOwner string) (gitRemote, bool) {
+	for _, r := range remotes {
+		if strings.EqualFold(r.Repo.Owner+"/"+r.Repo.Name, nameWithOwner) {
+			return r, true
+		}
+	}
+	return gitRemote{}, false
+}
+
+// forkParents memoizes the lookups of forkParent, as baseRemote runs
+// several times per invocation.
+var forkParents = struct {
+	sync.Mutex
+	m map[repository.Repository]string
+}{m: map[repository.Repository]string{}}
+
+// forkParent returns the OWNER/REPO that repo was forked from, or "" when it
+// is not a fork or the lookup fails. Only successful lookups are kept, and
+// concurrent callers wait for the first one rather than repeating it.
+func forkParent(ctx context.Context, repo repository.Repository) string {
+	forkParents.Lock()
+	defer forkParents.Unlock()
+	if parent, ok := forkParents.m[repo]; ok {
+		return parent
+	}
+	parent, err := lookupForkParent(ctx, repo)
+	if err == nil {
+		forkParents.m[repo] = parent
+	}
+	return parent
+}
+
+func lookupForkParent(ctx context.Context, repo repository.Repository) (string, error) {
+	client, err := api.NewGraphQLClient(api.ClientOptions{Host: repo.Host})
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Repository struct {
+			Parent *struct {
+				NameWithOwner string `json:"nameWithOwner"`
+			} `json:"parent"`
+		} `json:"repository"`
+	}
+	query := `query($owner: String!, $name: String!) {
+		repository(owner: $owner, name: $name) { parent { nameWithOwner } }
+	}`
+	vars := map[string]interface{}{"owner": repo.Owner, "name": repo.Name}
+	if err := client.DoWithContext(ctx, query, vars, &resp); err != nil {
+		return "", err
+	}
+	if resp.Repository.Parent == nil {
+		return "", nil
+	}
+	return resp.Repository.Parent.NameWithOwner, nil
+}
+
+// currentRepo returns the base repository of the working directory, or the
+// GH_REPO override.
+func currentRepo(ctx context.Context, remoteOverride string) (repository.Repository, error) {
+	if os.Getenv("GH_REPO") != "" {
+		return repository.Current()
+	}
+	r, err := baseRemote(ctx, remoteOverride)
+	if err != nil {
+		return repository.Repository{}, err
+	}
+	return r.Repo, nil
+}
+
+// repoArg formats repo for gh's -R flag.
+func repoArg(repo repository.Repository) string {
+	return repo.Host + "/" + repo.Owner + "/" + repo.Name
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+func TestBaseRemote(t *testing.T) {
+	git := initTestRepo(t)
+	ctx := context.Background()
+
+	if _, err := baseRemote(ctx, ""); err == nil {
+		t.Error("baseRemote() without remotes should fail")
+	}
+
+	git(nil, "remote", "add", "origin", "https://github.com/me/r.git")
+	git(nil, "remote", "add", "mirror", "git@github.com:other/r.git")
+	git(nil, "remote", "add", "upstream", "git@github.com:o/r.git")
+
+	tests := []struct {
+		name     string
+		setup    func()
+		override string
+		want     string
+		wantRepo string
+	}{
+		{"prefers_upstream", func() {}, "", "upstream", "o/r"},
+		{"override", func() {}, "origin", "origin", "me/r"},
+		{"git_config", func() { git(nil, "config", "gh-list-pr.remote", "mirror") }, "", "mirror", "other/r"},
+		{"set_default_base", func() {
+			git(nil, "config", "--unset", "gh-list-pr.remote")
+			git(nil, "config", "remote.origin.gh-resolved", "base")
+		}, "", "origin", "me/r"},
+		{"set_default_repo", func() {
+			git(nil, "config", "remote.origin.gh-resolved", "other/r")
+		}, "", "mirror", "other/r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			got, err := baseRemote(ctx, tt.override)
+			if err != nil {
+				t.Fatalf("baseRemote() error = %v", err)
+			}
+			if got.Name != tt.want || got.Repo.Owner+"/"+got.Repo.Name != tt.wantRepo {
+				t.Errorf("baseRemote() = %s (%s/%s), want %s (%s)",
+					got.Name, got.Repo.Owner, got.Repo.Name, tt.want, tt.wantRepo)
+			}
+		})
+	}
+
+	if _, err := baseRemote(ctx, "missing"); err == nil {
+		t.Error("baseRemote() with an unknown override should fail")
+	}
+}
+
+func TestBaseRemoteForkParent(t *testing.T) {
+	git := initTestRepo(t)
+	git(nil, "remote", "add", "origin", "https://github.com/me/r.git")
+	git(nil, "remote", "add", "mirror", "git@github.com:o/r.git")
+
+	// A known parent is not looked up again.
+	origin := repository.Repository{Host: "github.com", Owner: "me", Name: "r"}
+	forkParents.Lock()
+	forkParents.m[origin] = "o/r"
+	forkParents.Unlock()
+	t.Cleanup(func() {
+		forkParents.Lock()
+		delete(forkParents.m, origin)
+		forkParents.Unlock()
+	})
+
+	for range 2 {
+		got, err := baseRemote(context.Background(), "")
+		if err != nil {
+			t.Fatalf("baseRemote() error = %v", err)
+		}
+		if got.Name != "mirror" {
+			t.Errorf("baseRemote() = %s, want the parent remote mirror", got.Name)
+		}
+	}
+}
+
+func TestGitRemotesOrder(t *testing.T) {
+	git := initTestRepo(t)
+	for _, name := range []string{"fork", "origin", "github", "upstream"} {
+		git(nil, "remote", "add", name, "https://github.com/"+name+"/r.git")
+	}
+	remotes, err := gitRemotes(context.Background())
+	if err != nil {
+		t.Fatalf("gitRemotes() error = %v", err)
+	}
+	var got []string
+	for _, r := range remotes {
+		got = append(got, r.Name)
+	}
+	want := []string{"upstream", "github", "origin", "fork"}
+	if len(got) != len(want) {
+		t.Fatalf("gitRemotes() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("gitRemotes() = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// splitShellWords splits s into words the way a POSIX shell does, honoring
+// single quotes, double quotes and backslash escapes. No expansion of any
+// kind is performed.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		escaped bool
+		quote   rune // '\'' or '"' while inside quotes
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+			if r == '\n' {
+				continue // line continuation
+			}
+			if quote == '"' && !strings.ContainsRune("$`\"\\", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	switch {
+	case escaped:
+		return nil, errors.New("trailing backslash")
+	case quote == '\'':
+		return nil, errors.New("unterminated single quote")
+	case quote == '"':
+		return nil, errors.New("unterminated double quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"blank", "  \t\n ", nil, false},
+		{"plain", "--state all --limit 100", []string{"--state", "all", "--limit", "100"}, false},
+		{"double_quotes", `--search "fix login bug"`, []string{"--search", "fix login bug"}, false},
+		{"single_quotes", `--header 'my header'`, []string{"--header", "my header"}, false},
+		{"quoted_equals", `--header='a b'`, []string{"--header=a b"}, false},
+		{"adjacent_quotes", `a"b c"'d e'f`, []string{"ab cd ef"}, false},
+		{"empty_quotes", `'' ""`, []string{"", ""}, false},
+		{"escaped_space", `a\ b c`, []string{"a b", "c"}, false},
+		{"escaped_quote", `"say \"hi\""`, []string{`say "hi"`}, false},
+		{"backslash_in_double_quotes", `"a\b"`, []string{`a\b`}, false},
+		{"backslash_in_single_quotes", `'a\b'`, []string{`a\b`}, false},
+		{"single_quote_in_double", `"it's"`, []string{"it's"}, false},
+		{"line_continuation", "a\\\nb", []string{"ab"}, false},
+		{"dollar_not_expanded", `"$HOME"`, []string{"$HOME"}, false},
+		{"unterminated_single", `'abc`, nil, true},
+		{"unterminated_double", `"abc`, nil, true},
+		{"trailing_backslash", `abc\`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShellWords(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("splitShellWords(%q) = %q, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitShellWords(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitShellWords(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
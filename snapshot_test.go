@@ -12,15 +12,23 @@ var update = flag.Bool("update", false, "update golden files")
 
 var snapshotPRs = []PullRequest{
 	{
-		Number:       1,
-		Title:        "Add new feature",
-		HeadRefName:  "feature/add-new",
-		AuthorName:   "alice",
-		CreatedAt:    "2025-01-15T10:00:00Z",
-		IsDraft:      false,
-		Additions:    42,
-		Deletions:    10,
-		ChangedFiles: 5,
+		Number:         1,
+		Title:          "Add new feature",
+		HeadRefName:    "feature/add-new",
+		AuthorName:     "alice",
+		CreatedAt:      "2025-01-15T10:00:00Z",
+		IsDraft:        false,
+		Additions:      42,
+		Deletions:      10,
+		ChangedFiles:   5,
+		Checks:         Checks{Pass: 5},
+		LatestReviews:  []Review{{Author: "bob", State: "APPROVED"}},
+		ReviewDecision: "APPROVED",
+
+		Mergeable:        "MERGEABLE",
+		MergeStateStatus: "CLEAN",
+
+		Comments: 2,
 	},
 	{
 		Number:       23,
@@ -32,33 +40,59 @@ var snapshotPRs = []PullRequest{
 		Additions:    150,
 		Deletions:    200,
 		ChangedFiles: 15,
+		Checks:       Checks{Pass: 2, Pending: 3},
+
+		Mergeable:        "MERGEABLE",
+		MergeStateStatus: "DRAFT",
 	},
 	{
-		Number:       456,
-		Title:        "日本語のタイトル",
-		HeadRefName:  "fix/i18n-support",
-		AuthorName:   "charlie",
-		CreatedAt:    "2025-01-17T14:00:00Z",
-		IsDraft:      false,
-		Additions:    5,
-		Deletions:    3,
-		ChangedFiles: 2,
+		Number:         456,
+		Title:          "日本語のタイトル",
+		HeadRefName:    "fix/i18n-support",
+		AuthorName:     "charlie",
+		CreatedAt:      "2025-01-17T14:00:00Z",
+		IsDraft:        false,
+		Additions:      5,
+		Deletions:      3,
+		ChangedFiles:   2,
+		ReviewRequests: []string{"alice", "core-team"},
+		ReviewDecision: "REVIEW_REQUIRED",
+		Labels:         []Label{{Name: "i18n", Color: "1d76db"}},
+
+		Mergeable:        "MERGEABLE",
+		MergeStateStatus: "BLOCKED",
+
+		Comments:          5,
+		ReviewThreads:     3,
+		UnresolvedThreads: 1,
 	},
 	{
-		Number:       7890,
-		Title:        "Big changes everywhere",
-		HeadRefName:  "release/v2.0",
-		AuthorName:   "dave",
-		CreatedAt:    "2025-01-18T16:00:00Z",
-		IsDraft:      false,
-		Additions:    1234,
-		Deletions:    567,
-		ChangedFiles: 89,
+		Number:         7890,
+		Title:          "Big changes everywhere",
+		HeadRefName:    "release/v2.0",
+		AuthorName:     "dave",
+		CreatedAt:      "2025-01-18T16:00:00Z",
+		IsDraft:        false,
+		Additions:      1234,
+		Deletions:      567,
+		ChangedFiles:   89,
+		Checks:         Checks{Pass: 10, Fail: 2},
+		LatestReviews:  []Review{{Author: "alice", State: "CHANGES_REQUESTED"}},
+		ReviewDecision: "CHANGES_REQUESTED",
+		Labels:         []Label{{Name: "hotfix", Color: "b60205"}, {Name: "needs-qa", Color: "fbca04"}},
+
+		Mergeable:        "CONFLICTING",
+		MergeStateStatus: "DIRTY",
+
+		Comments:          14,
+		ReviewThreads:     8,
+		UnresolvedThreads: 4,
 	},
 }
 
 func TestSnapshot(t *testing.T) {
 	t.Setenv("FZF_DEFAULT_OPTS", "")
+	t.Setenv("COLORTERM", "truecolor")
 
 	tests := []struct {
 		name   string
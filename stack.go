@@ -0,0 +1,76 @@
+package main
+
+// Tree prefixes drawn before the titles of stacked PRs.
+const (
+	treeBranch = "├─ "
+	treeLast   = "└─ "
+	treeLine   = "│  "
+	treeBlank  = "   "
+)
+
+// stackPRs orders prs as a forest in which a PR whose base branch is
+// another PR's head branch follows that PR, and sets TreePrefix on the
+// stacked ones. PRs only stack within the same repository and --inbox
+// section, and only on PRs from that repository: a fork's head branch may
+// share its name with any base branch. Otherwise the order of prs is kept.
+func stackPRs(prs []PullRequest) []PullRequest {
+	type headKey struct {
+		section, repo, branch string
+	}
+	heads := map[headKey]int{}
+	for i, pr := range prs {
+		k := headKey{pr.Section, pr.repoKey(), pr.HeadRefName}
+		if _, ok := heads[k]; !ok && pr.Number != 0 && !pr.IsCrossRepository {
+			heads[k] = i
+		}
+	}
+
+	stacked := make([]bool, len(prs))
+	children := map[int][]int{}
+	for i, pr := range prs {
+		if pr.Number == 0 || pr.BaseRefName == "" {
+			continue
+		}
+		if p, ok := heads[headKey{pr.Section, pr.repoKey(), pr.BaseRefName}]; ok && p != i {
+			stacked[i] = true
+			children[p] = append(children[p], i)
+		}
+	}
+
+	out := make([]PullRequest, 0, len(prs))
+	visited := make([]bool, len(prs))
+	var walk func(i int, prefix, indent string)
+	walk = func(i int, prefix, indent string) {
+		visited[i] = true
+		pr := prs[i]
+		pr.TreePrefix = prefix
+		out = append(out, pr)
+
+		var kids []int
+		for _, c := range children[i] {
+			if !visited[c] {
+				kids = append(kids, c)
+			}
+		}
+		for k, c := range kids {
+			if k == len(kids)-1 {
+				walk(c, indent+treeLast, indent+treeBlank)
+			} else {
+				walk(c, indent+treeBranch, indent+treeLine)
+			}
+		}
+	}
+	for i := range prs {
+		if !stacked[i] && !visited[i] {
+			walk(i, "", "")
+		}
+	}
+	// PRs stacked on each other in a cycle have no root; start from the
+	// first of them.
+	for i := range prs {
+		if !visited[i] {
+			walk(i, "", "")
+		}
+	}
+	return out
+}
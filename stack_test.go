@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStackPRs(t *testing.T) {
+	type row struct {
+		Number int
+		Prefix string
+	}
+	tests := []struct {
+		name string
+		prs  []PullRequest
+		want []row
+	}{
+		{
+			"flat",
+			[]PullRequest{
+				{Number: 1, HeadRefName: "a", BaseRefName: "main"},
+				{Number: 2, HeadRefName: "b", BaseRefName: "main"},
+			},
+			[]row{{1, ""}, {2, ""}},
+		},
+		{
+			"stack",
+			[]PullRequest{
+				{Number: 4, HeadRefName: "part3", BaseRefName: "part2"},
+				{Number: 3, HeadRefName: "part2b", BaseRefName: "part1"},
+				{Number: 2, HeadRefName: "part2", BaseRefName: "part1"},
+				{Number: 1, HeadRefName: "part1", BaseRefName: "main"},
+				{Number: 0, HeadRefName: "main"},
+			},
+			[]row{{1, ""}, {3, "├─ "}, {2, "└─ "}, {4, "   └─ "}, {0, ""}},
+		},
+		{
+			"deep_sibling_line",
+			[]PullRequest{
+				{Number: 1, HeadRefName: "p1", BaseRefName: "main"},
+				{Number: 2, HeadRefName: "p2", BaseRefName: "p1"},
+				{Number: 3, HeadRefName: "p3", BaseRefName: "p2"},
+				{Number: 4, HeadRefName: "q2", BaseRefName: "p1"},
+			},
+			[]row{{1, ""}, {2, "├─ "}, {3, "│  └─ "}, {4, "└─ "}},
+		},
+		{
+			"other_repo_or_section",
+			[]PullRequest{
+				{Number: 1, Repository: "o/a", HeadRefName: "p1", BaseRefName: "main"},
+				{Number: 2, Repository: "o/b", HeadRefName: "p2", BaseRefName: "p1"},
+				{Number: 3, Repository: "o/a", HeadRefName: "p3", BaseRefName: "p1", Section: "Mentioning you"},
+			},
+			[]row{{1, ""}, {2, ""}, {3, ""}},
+		},
+		{
+			"fork_head_named_like_base",
+			[]PullRequest{
+				{Number: 3, HeadRefName: "main", BaseRefName: "main", IsCrossRepository: true},
+				{Number: 2, HeadRefName: "feat", BaseRefName: "main"},
+				{Number: 1, HeadRefName: "fix", BaseRefName: "feat"},
+			},
+			[]row{{3, ""}, {2, ""}, {1, "└─ "}},
+		},
+		{
+			"cycle",
+			[]PullRequest{
+				{Number: 1, HeadRefName: "x", BaseRefName: "y"},
+				{Number: 2, HeadRefName: "y", BaseRefName: "x"},
+			},
+			[]row{{1, ""}, {2, "└─ "}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []row
+			for _, pr := range stackPRs(tt.prs) {
+				got = append(got, row{pr.Number, pr.TreePrefix})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stackPRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
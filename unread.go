@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// seenPR is the state of a PR the last time it was listed.
+type seenPR struct {
+	UpdatedAt  string `json:"updatedAt"`
+	HeadRefOid string `json:"headRefOid"`
+}
+
+// seenPRs maps a repository, as HOST/OWNER/NAME (see repoKey), to the state
+// of its PRs by number.
+type seenPRs map[string]map[int]seenPR
+
+func seenPRsPath() string {
+	return filepath.Join(emojiCacheDir(), "seen.json")
+}
+
+func loadSeenPRs() seenPRs {
+	seen := seenPRs{}
+	if data, err := os.ReadFile(seenPRsPath()); err == nil {
+		_ = json.Unmarshal(data, &seen)
+	}
+	return seen
+}
+
+func saveSeenPRs(seen seenPRs) error {
+	path := seenPRsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(seen)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// clone copies seen, so that recording into one leaves the other as is.
+func (seen seenPRs) clone() seenPRs {
+	c := make(seenPRs, len(seen))
+	for repo, byNumber := range seen {
+		c[repo] = maps.Clone(byNumber)
+	}
+	return c
+}
+
+// markUnread sets Unread on the PRs that were pushed to, commented on or
+// otherwise updated since they were last listed, and on PRs new to a
+// repository listed before. Nothing is unread in a repository seen for the
+// first time, so that the first run does not highlight every row.
+func markUnread(prs []PullRequest, seen seenPRs) {
+	for i, pr := range prs {
+		if pr.Number == 0 {
+			continue
+		}
+		byNumber, ok := seen[pr.repoKey()]
+		if !ok {
+			continue
+		}
+		last, ok := byNumber[pr.Number]
+		prs[i].Unread = !ok || last.HeadRefOid != pr.HeadRefOid || pr.UpdatedAt > last.UpdatedAt
+	}
+}
+
+// recordSeen remembers the current state of prs, which have been listed.
+func recordSeen(prs []PullRequest, seen seenPRs) {
+	for _, pr := range prs {
+		if pr.Number == 0 {
+			continue
+		}
+		key := pr.repoKey()
+		if seen[key] == nil {
+			seen[key] = map[int]seenPR{}
+		}
+		seen[key][pr.Number] = seenPR{UpdatedAt: pr.UpdatedAt, HeadRefOid: pr.HeadRefOid}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkUnread(t *testing.T) {
+	seen := seenPRs{
+		"github.com/o/r": {
+			1: {UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "aaa"},
+			2: {UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "bbb"},
+			3: {UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "ccc"},
+		},
+	}
+	prs := []PullRequest{
+		{Number: 1, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "aaa"},
+		{Number: 2, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-02T00:00:00Z", HeadRefOid: "bbb"},
+		{Number: 3, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "ddd"},
+		{Number: 4, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "eee"},
+		{Number: 5, Repository: "o/other", Host: "github.com", UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "fff"},
+		{Number: 0, HeadRefName: "main"},
+	}
+	markUnread(prs, seen)
+
+	var got []int
+	for _, pr := range prs {
+		if pr.Unread {
+			got = append(got, pr.Number)
+		}
+	}
+	// 2 was commented on, 3 pushed to and 4 is new; o/other was never listed.
+	if want := []int{2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unread PRs = %v, want %v", got, want)
+	}
+
+	recordSeen(prs, seen)
+	markUnread(prs, seen)
+	for _, pr := range prs {
+		if pr.Unread {
+			t.Errorf("PR %s#%d unread after being recorded", pr.Repository, pr.Number)
+		}
+	}
+	if _, ok := seen[""]; ok {
+		t.Error("branch rows should not be recorded")
+	}
+}
+
+func TestMarkUnreadHosts(t *testing.T) {
+	// The same OWNER/NAME on another host is another repository.
+	seen := seenPRs{"github.com/o/r": {1: {UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "aaa"}}}
+	prs := []PullRequest{
+		{Number: 1, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "aaa"},
+		{Number: 2, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "bbb"},
+		{Number: 1, Repository: "o/r", Host: "ghes.example.com", UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "ccc"},
+	}
+	markUnread(prs, seen)
+	if !prs[1].Unread || prs[0].Unread || prs[2].Unread {
+		t.Errorf("unread = %v %v %v, want only github.com/o/r#2", prs[0].Unread, prs[1].Unread, prs[2].Unread)
+	}
+	recordSeen(prs, seen)
+	if _, ok := seen["ghes.example.com/o/r"][1]; !ok {
+		t.Errorf("seen = %v, want ghes.example.com/o/r recorded", seen)
+	}
+}
+
+func TestSeenPRsClone(t *testing.T) {
+	seen := seenPRs{"github.com/o/r": {1: {UpdatedAt: "2025-01-01T00:00:00Z"}}}
+	before := seen.clone()
+	recordSeen([]PullRequest{{Number: 1, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-02T00:00:00Z"}}, seen)
+
+	// A PR unread before the cached rows were recorded stays unread.
+	prs := []PullRequest{{Number: 1, Repository: "o/r", Host: "github.com", UpdatedAt: "2025-01-02T00:00:00Z"}}
+	markUnread(prs, before)
+	if !prs[0].Unread {
+		t.Error("markUnread() against the clone should still mark the PR unread")
+	}
+	if got := before["github.com/o/r"][1].UpdatedAt; got != "2025-01-01T00:00:00Z" {
+		t.Errorf("clone changed by recordSeen: UpdatedAt = %q", got)
+	}
+}
+
+func TestSeenPRsRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if got := loadSeenPRs(); len(got) != 0 {
+		t.Errorf("loadSeenPRs() without a file = %v, want empty", got)
+	}
+	want := seenPRs{"o/r": {42: {UpdatedAt: "2025-01-01T00:00:00Z", HeadRefOid: "abc"}}}
+	if err := saveSeenPRs(want); err != nil {
+		t.Fatalf("saveSeenPRs() error = %v", err)
+	}
+	if got := loadSeenPRs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("loadSeenPRs() = %v, want %v", got, want)
+	}
+}